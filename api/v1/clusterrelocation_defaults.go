@@ -0,0 +1,123 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"net"
+	"strconv"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	DefaultManagedClusterSet    = "default"
+	DefaultRegistryPollInterval = "24h"
+)
+
+// Default sets the defaults of the optional fields, so that the stored spec shows the effective configuration.
+// It is called by the mutating webhook, and by the controller in case the webhook isn't deployed.
+// The simple defaults are also declared as CRD defaults.
+func (r *ClusterRelocation) Default() {
+	spec := &r.Spec
+
+	if spec.Mode == "" {
+		spec.Mode = ModeApply
+	}
+
+	if spec.AddInternalDNSEntries == nil {
+		addInternalDNSEntries := false
+		spec.AddInternalDNSEntries = &addInternalDNSEntries
+	}
+
+	for i := range spec.CatalogSources {
+		if spec.CatalogSources[i].RegistryPollInterval == "" {
+			spec.CatalogSources[i].RegistryPollInterval = DefaultRegistryPollInterval
+		}
+	}
+
+	for i := range spec.RegistryCerts {
+		registryCert := &spec.RegistryCerts[i]
+		if registryCert.RegistryPort != nil {
+			continue
+		}
+		// the registry is often given as <hostname>:<port>
+		if host, port, err := net.SplitHostPort(registryCert.RegistryHostname); err == nil {
+			if portNumber, err := strconv.Atoi(port); err == nil {
+				registryCert.RegistryHostname = host
+				registryCert.RegistryPort = &portNumber
+			}
+		}
+	}
+
+	if spec.ACMRegistration != nil && spec.ACMRegistration.ManagedClusterSet == nil {
+		managedClusterSet := DefaultManagedClusterSet
+		spec.ACMRegistration.ManagedClusterSet = &managedClusterSet
+	}
+
+	if spec.API != nil && spec.API.KeepOriginalDomain == nil {
+		keepOriginalDomain := false
+		spec.API.KeepOriginalDomain = &keepOriginalDomain
+	}
+
+	if spec.RouteReset != nil {
+		spec.RouteReset.Policy = spec.RouteReset.GetPolicy()
+		if spec.RouteReset.Exclusions == nil {
+			spec.RouteReset.Exclusions = append([]string{}, DefaultRouteResetExclusions...)
+		}
+	}
+
+	if spec.CertManager != nil {
+		spec.CertManager.Namespace = spec.CertManager.GetNamespace()
+		spec.CertManager.IssuerRef.Kind = spec.CertManager.IssuerRef.GetKind()
+		spec.CertManager.IssuerRef.Group = spec.CertManager.IssuerRef.GetGroup()
+	}
+
+	if spec.CertificateGeneration != nil {
+		// the default key size depends on the key algorithm, so it isn't declared in the CRD
+		spec.CertificateGeneration.KeyAlgorithm = spec.CertificateGeneration.GetKeyAlgorithm()
+		if spec.CertificateGeneration.KeySize == nil {
+			keySize := spec.CertificateGeneration.GetKeySize()
+			spec.CertificateGeneration.KeySize = &keySize
+		}
+		if spec.CertificateGeneration.Validity == nil {
+			spec.CertificateGeneration.Validity = &metav1.Duration{Duration: DefaultCertificateValidity}
+		}
+		if spec.CertificateGeneration.RenewBefore == nil {
+			spec.CertificateGeneration.RenewBefore = &metav1.Duration{Duration: DefaultCertificateRenewBefore}
+		}
+	}
+
+	if spec.Timeouts == nil {
+		spec.Timeouts = &Timeouts{}
+	}
+	for _, v := range []struct {
+		timeout      **metav1.Duration
+		defaultValue metav1.Duration
+	}{
+		{&spec.Timeouts.ClusterOperatorSettle, metav1.Duration{Duration: DefaultClusterOperatorSettleTimeout}},
+		{&spec.Timeouts.MachineConfigPoolUpdate, metav1.Duration{Duration: DefaultMachineConfigPoolUpdateTimeout}},
+		{&spec.Timeouts.ACMImport, metav1.Duration{Duration: DefaultACMImportTimeout}},
+		{&spec.Timeouts.KlusterletAvailable, metav1.Duration{Duration: DefaultKlusterletAvailableTimeout}},
+		{&spec.Timeouts.EndpointVerification, metav1.Duration{Duration: DefaultEndpointVerificationTimeout}},
+		{&spec.Timeouts.CertificateIssuance, metav1.Duration{Duration: DefaultCertificateIssuanceTimeout}},
+	} {
+		if *v.timeout == nil {
+			defaultValue := v.defaultValue
+			*v.timeout = &defaultValue
+		}
+	}
+}
@@ -0,0 +1,1090 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	agentv1 "github.com/stolostron/klusterlet-addon-controller/pkg/apis/agent/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterRelocationSpec defines the desired state of ClusterRelocation
+type ClusterRelocationSpec struct {
+	// Important: Run "make" to regenerate code after modifying this file
+
+	// ACMRegistration allows you to register this cluster to a remote ACM cluster.
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	ACMRegistration *ACMRegistration `json:"acmRegistration,omitempty"`
+
+	// AddInternalDNSEntries deploys MachineConfigs which add api, api-int and *.apps entries for the new domain to dnsmasq.
+	// On SNO, the entries point at the node. On multi-node clusters, they are only added when DNS.NetworkManagerDNSMasq is true,
+	// and they point at the API and ingress VIPs of the cluster, which are only available on the on-premise platforms (e.g. baremetal or vSphere).
+	// Setting this to true will cause a reboot of the nodes.
+	// If you don't enable this option, you need to make sure that the cluster can resolve the new domain address via some other method.
+	// Defaults to false.
+	//+kubebuilder:default=false
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	AddInternalDNSEntries *bool `json:"addInternalDNSEntries,omitempty"`
+
+	// API configures the hostnames that the API server is served on, in addition to api.<domain>.
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	API *API `json:"api,omitempty"`
+
+	// APICertRef is a reference to a TLS secret that will be used for the API server.
+	// If it is omitted, the certificate is requested from cert-manager when CertManager is set, otherwise it will be generated and signed by the CA selected in CertificateGeneration.CA.
+	// The type of the secret must be kubernetes.io/tls.
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	APICertRef *corev1.SecretReference `json:"apiCertRef,omitempty"`
+
+	// CatalogSources define new CatalogSources to install on the cluster.
+	//+listType=map
+	//+listMapKey=name
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	CatalogSources []CatalogSource `json:"catalogSources,omitempty"`
+
+	// CertificateGeneration configures the certificates which are generated when APICertRef or IngressCertRef is omitted.
+	// The certificates are generated again whenever these settings change.
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	CertificateGeneration *CertificateGeneration `json:"certificateGeneration,omitempty"`
+
+	// CertManager requests the certificates which are not given by APICertRef or IngressCertRef from cert-manager, instead of generating them.
+	// The key settings, validity, renewal and additional names of CertificateGeneration are passed on to the cert-manager Certificates.
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	CertManager *CertManager `json:"certManager,omitempty"`
+
+	// DNS configures the DNS of the new domain. Addresses and Records only apply to the internal DNS entries, which are added when AddInternalDNSEntries is true.
+	// ClusterDNS, Forwarders (through ClusterDNS) and Preflight apply regardless of AddInternalDNSEntries, and Preflight checks that the new domain resolves to Addresses when they are set.
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	DNS *DNS `json:"dns,omitempty"`
+
+	// Domain defines the new base domain for the cluster.
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	Domain string `json:"domain"`
+
+	// ImageDigestMirrors is used to configured a mirror registry on the cluster.
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	ImageDigestMirrors []configv1.ImageDigestMirrors `json:"imageDigestMirrors,omitempty"`
+
+	// IngressCertRef is a reference to a TLS secret that will be used for the Ingress Controller.
+	// If it is omitted, the certificate is requested from cert-manager when CertManager is set, otherwise it will be generated and signed by the CA selected in CertificateGeneration.CA.
+	// The type of the secret must be kubernetes.io/tls.
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	IngressCertRef *corev1.SecretReference `json:"ingressCertRef,omitempty"`
+
+	// IngressControllers are additional IngressControllers to relocate (e.g. router shards), along with the default one.
+	// The default IngressController is relocated with IngressCertRef, and can't be listed here.
+	//+listType=map
+	//+listMapKey=name
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	IngressControllers []IngressController `json:"ingressControllers,omitempty"`
+
+	// ComponentRoutes are customizations of the routes of the cluster components (see the componentRoutes of the cluster Ingress status).
+	// The console, downloads and oauth-openshift routes are always moved to the new domain, these entries can change their hostname or certificate, or add other routes.
+	// They are merged with the componentRoutes of the cluster Ingress, which are restored when the CR is deleted.
+	//+listType=map
+	//+listMapKey=namespace
+	//+listMapKey=name
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	ComponentRoutes []ComponentRoute `json:"componentRoutes,omitempty"`
+
+	// RouteReset configures how the Routes are moved to the new domain, once it is served by the ingress.
+	// By default, the Routes which are not in the new domain are deleted, so that they are re-created by their owners.
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	RouteReset *RouteReset `json:"routeReset,omitempty"`
+
+	// Mode defines whether the relocation is applied to the cluster, or only planned. Defaults to 'Apply'.
+	// In Plan mode, the changes that the relocation would make are computed using dry-run requests and reported in status.plan.
+	// Nothing is applied to the cluster.
+	//+kubebuilder:validation:Enum=Apply;Plan
+	//+kubebuilder:default=Apply
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	Mode RelocationMode `json:"mode,omitempty"`
+
+	// PullSecretRef is a reference to new cluster-wide pull secret.
+	// If defined, it will replace the secret located at openshift-config/pull-secret.
+	// The type of the secret must be kubernetes.io/dockerconfigjson.
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	PullSecretRef *corev1.SecretReference `json:"pullSecretRef,omitempty"`
+
+	// RegistryCerts are new trusted CA certificates, one per registry.
+	// They will be added to image.config.openshift.io/cluster (additionalTrustedCA).
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	RegistryCerts []RegistryCert `json:"registryCerts,omitempty"`
+
+	// SSH defines new authorized SSH keys for the 'core' user.
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	SSH *SSH `json:"ssh,omitempty"`
+
+	// Timeouts defines how long the relocation waits for the cluster to converge before a step times out.
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	Timeouts *Timeouts `json:"timeouts,omitempty"`
+
+	// Verification configures how the certificates served by the API server and the ingress are verified, once they have been relocated.
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	Verification *Verification `json:"verification,omitempty"`
+}
+
+// ClusterRelocationStatus defines the observed state of ClusterRelocation
+type ClusterRelocationStatus struct {
+	// Important: Run "make" to regenerate code after modifying this file
+
+	// Conditions represent the latest available observations of an object's state
+	//+operator-sdk:csv:customresourcedefinitions:type=status
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Steps reports the progress of each step of the relocation.
+	//+listType=map
+	//+listMapKey=name
+	//+operator-sdk:csv:customresourcedefinitions:type=status
+	Steps []StepStatus `json:"steps,omitempty"`
+
+	// Plan reports the changes that the relocation would make to the cluster, when the mode is Plan.
+	//+operator-sdk:csv:customresourcedefinitions:type=status
+	Plan *PlanStatus `json:"plan,omitempty"`
+
+	// DNSLookups reports the results of the most recent preflight lookups of the new domain.
+	//+operator-sdk:csv:customresourcedefinitions:type=status
+	DNSLookups []DNSLookup `json:"dnsLookups,omitempty"`
+
+	// Endpoints reports the certificates served by the API server and the ingress, and whether they could be verified.
+	//+listType=map
+	//+listMapKey=name
+	//+operator-sdk:csv:customresourcedefinitions:type=status
+	Endpoints []EndpointStatus `json:"endpoints,omitempty"`
+
+	// Certificates reports the certificates generated by the operator, and when they will be renewed.
+	//+listType=map
+	//+listMapKey=name
+	//+operator-sdk:csv:customresourcedefinitions:type=status
+	Certificates []CertificateStatus `json:"certificates,omitempty"`
+
+	// Routes reports the Routes which were deleted or rewritten to move them to the new domain.
+	// The hostname of the rewritten Routes is restored when the CR is deleted.
+	//+listType=map
+	//+listMapKey=namespace
+	//+listMapKey=name
+	//+operator-sdk:csv:customresourcedefinitions:type=status
+	Routes []RouteStatus `json:"routes,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:storageversion
+
+// ClusterRelocation is the Schema for the clusterrelocations API
+// +operator-sdk:csv:customresourcedefinitions:resources={{Secret,v1,"generated-api-secret"},{Secret,v1,"generated-ingress-secret"}}
+type ClusterRelocation struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterRelocationSpec   `json:"spec"`
+	Status ClusterRelocationStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ClusterRelocationList contains a list of ClusterRelocation
+type ClusterRelocationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterRelocation `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterRelocation{}, &ClusterRelocationList{})
+}
+
+type CatalogSource struct {
+	// Name is the name of the CatalogSource.
+	Name string `json:"name"`
+
+	// Image is an operator-registry container image to instantiate a registry-server with.
+	Image string `json:"image"`
+
+	// DisplayName is the name of the CatalogSource shown in the console.
+	DisplayName string `json:"displayName,omitempty"`
+
+	// Publisher is the publisher of the CatalogSource shown in the console.
+	Publisher string `json:"publisher,omitempty"`
+
+	// RegistryPollInterval is how often the CatalogSource polls its image for updates. Defaults to 24h.
+	//+kubebuilder:default="24h"
+	RegistryPollInterval string `json:"registryPollInterval,omitempty"`
+}
+
+type RegistryCert struct {
+	// RegistryHostname is the hostname of the new registry.
+	// If it is given as <hostname>:<port>, it is split into RegistryHostname and RegistryPort.
+	RegistryHostname string `json:"registryHostname"`
+
+	// RegistryPort is the port number that the registry is served on.
+	RegistryPort *int `json:"registryPort,omitempty"`
+
+	// Certificate is the certificate for the trusted certificate authority associated with the registry.
+	Certificate string `json:"certificate"`
+}
+
+type DNS struct {
+	// NetworkManagerDNSMasq enables the dnsmasq plugin of NetworkManager on the master and worker nodes of multi-node clusters,
+	// so that the internal DNS entries can be added to it. The nodes of multi-node clusters don't run dnsmasq otherwise,
+	// and the plugin may conflict with the DNS services which already run on the nodes (e.g. the CoreDNS static pods of the on-premise platforms),
+	// so it must be enabled explicitly. Without it, the internal DNS entries are only added on SNO.
+	// Consider ClusterDNS instead, which doesn't reboot the nodes. Defaults to false.
+	NetworkManagerDNSMasq *bool `json:"networkManagerDNSMasq,omitempty"`
+
+	// Addresses overrides the IP addresses that the internal DNS entries point at.
+	// By default, on SNO they point at the internal IP address of the node for each IP family (IPv4 and IPv6),
+	// and on multi-node clusters they point at the API and ingress VIPs.
+	Addresses *DNSAddresses `json:"addresses,omitempty"`
+
+	// Records are additional DNS records, e.g. for a registry mirror, an NTP server or the ACM hub,
+	// which need to resolve before the external DNS records exist.
+	//+listType=map
+	//+listMapKey=name
+	Records []DNSRecord `json:"records,omitempty"`
+
+	// Forwarders send the queries for a zone to specific upstream DNS servers, instead of the default ones.
+	// They are added to the internal DNS entries, and to the cluster DNS if ClusterDNS is set.
+	//+listType=map
+	//+listMapKey=zone
+	Forwarders []DNSForwarder `json:"forwarders,omitempty"`
+
+	// ClusterDNS configures forwarding zones in the cluster DNS operator (dns.operator.openshift.io/default),
+	// for the new base domain and for the Forwarders. Unlike the internal DNS entries, this doesn't require a reboot,
+	// and doesn't depend on the platform, but it only affects the pods which use the cluster DNS (not the nodes).
+	// The original servers of the cluster DNS are restored when the CR is deleted.
+	ClusterDNS *ClusterDNS `json:"clusterDNS,omitempty"`
+
+	// Preflight configures how the relocation verifies that the new domain resolves, before the certificates are changed.
+	Preflight *DNSPreflight `json:"preflight,omitempty"`
+}
+
+type DNSPreflight struct {
+	// Nameservers are the DNS servers which are queried, as <IP> or <IP>:<port> ([<IPv6>]:<port> for IPv6).
+	// Defaults to the cluster DNS service (dns-default in openshift-dns).
+	Nameservers []string `json:"nameservers,omitempty"`
+}
+
+type ClusterDNS struct {
+	// Upstreams are the site DNS servers that resolve the new base domain, as <IP> or <IP>:<port> ([<IPv6>]:<port> for IPv6).
+	// If it is empty, only the Forwarders are added to the cluster DNS.
+	Upstreams []string `json:"upstreams,omitempty"`
+}
+
+type DNSRecord struct {
+	// Name is the name of the record, e.g. registry.example.com.
+	// A wildcard name (e.g. *.mirror.example.com) resolves mirror.example.com, and every name under it.
+	Name string `json:"name"`
+
+	// Addresses are the IPv4 and IPv6 addresses that the name resolves to.
+	//+kubebuilder:validation:MinItems=1
+	Addresses []string `json:"addresses"`
+}
+
+type DNSForwarder struct {
+	// Zone is the DNS zone which is forwarded, e.g. example.com. The names under the zone are forwarded as well.
+	Zone string `json:"zone"`
+
+	// Upstreams are the DNS servers that the zone is forwarded to, as <IP> or <IP>:<port> ([<IPv6>]:<port> for IPv6).
+	//+kubebuilder:validation:MinItems=1
+	Upstreams []string `json:"upstreams"`
+}
+
+type DNSAddresses struct {
+	// API are the IP addresses that api and api-int resolve to, e.g. one IPv4 and one IPv6 address on dual-stack clusters.
+	//+kubebuilder:validation:MinItems=1
+	API []string `json:"api"`
+
+	// Ingress are the IP addresses that *.apps resolves to.
+	//+kubebuilder:validation:MinItems=1
+	Ingress []string `json:"ingress"`
+}
+
+type SSH struct {
+	// AuthorizedKeys is a list of authorized SSH keys for the 'core' user.
+	// They will be appended to the existing authorized SSH key(s).
+	//+kubebuilder:validation:MinItems=1
+	AuthorizedKeys []string `json:"authorizedKeys"`
+
+	// Roles are the MachineConfigPool roles whose nodes receive the keys. Defaults to master and worker.
+	Roles []string `json:"roles,omitempty"`
+}
+
+type Timeouts struct {
+	// ClusterOperatorSettle is how long to wait for a ClusterOperator to finish progressing, once it has been reconfigured. Defaults to 20m.
+	//+kubebuilder:default="20m0s"
+	ClusterOperatorSettle *metav1.Duration `json:"clusterOperatorSettle,omitempty"`
+
+	// MachineConfigPoolUpdate is how long to wait for a MachineConfigPool to apply a new MachineConfig. Defaults to 60m.
+	//+kubebuilder:default="1h0m0s"
+	MachineConfigPoolUpdate *metav1.Duration `json:"machineConfigPoolUpdate,omitempty"`
+
+	// ACMImport is how long to wait for the ACM import secret to become available. Defaults to 5m.
+	//+kubebuilder:default="5m0s"
+	ACMImport *metav1.Duration `json:"acmImport,omitempty"`
+
+	// KlusterletAvailable is how long to wait for the Klusterlet to become Available. Defaults to 5m.
+	//+kubebuilder:default="5m0s"
+	KlusterletAvailable *metav1.Duration `json:"klusterletAvailable,omitempty"`
+
+	// EndpointVerification is how long to wait for the API server and the ingress to serve the new certificates. Defaults to 30m.
+	//+kubebuilder:default="30m0s"
+	EndpointVerification *metav1.Duration `json:"endpointVerification,omitempty"`
+
+	// CertificateIssuance is how long to wait for cert-manager to issue a certificate. Defaults to 10m.
+	//+kubebuilder:default="10m0s"
+	CertificateIssuance *metav1.Duration `json:"certificateIssuance,omitempty"`
+}
+
+const (
+	DefaultClusterOperatorSettleTimeout   = 20 * time.Minute
+	DefaultMachineConfigPoolUpdateTimeout = 60 * time.Minute
+	DefaultACMImportTimeout               = 5 * time.Minute
+	DefaultKlusterletAvailableTimeout     = 5 * time.Minute
+	DefaultEndpointVerificationTimeout    = 30 * time.Minute
+	DefaultCertificateIssuanceTimeout     = 10 * time.Minute
+)
+
+// GetClusterOperatorSettle returns the ClusterOperatorSettle timeout, or its default if it is not set
+func (t *Timeouts) GetClusterOperatorSettle() time.Duration {
+	if t == nil || t.ClusterOperatorSettle == nil {
+		return DefaultClusterOperatorSettleTimeout
+	}
+	return t.ClusterOperatorSettle.Duration
+}
+
+// GetMachineConfigPoolUpdate returns the MachineConfigPoolUpdate timeout, or its default if it is not set
+func (t *Timeouts) GetMachineConfigPoolUpdate() time.Duration {
+	if t == nil || t.MachineConfigPoolUpdate == nil {
+		return DefaultMachineConfigPoolUpdateTimeout
+	}
+	return t.MachineConfigPoolUpdate.Duration
+}
+
+// GetACMImport returns the ACMImport timeout, or its default if it is not set
+func (t *Timeouts) GetACMImport() time.Duration {
+	if t == nil || t.ACMImport == nil {
+		return DefaultACMImportTimeout
+	}
+	return t.ACMImport.Duration
+}
+
+// GetKlusterletAvailable returns the KlusterletAvailable timeout, or its default if it is not set
+func (t *Timeouts) GetKlusterletAvailable() time.Duration {
+	if t == nil || t.KlusterletAvailable == nil {
+		return DefaultKlusterletAvailableTimeout
+	}
+	return t.KlusterletAvailable.Duration
+}
+
+// GetEndpointVerification returns the EndpointVerification timeout, or its default if it is not set
+func (t *Timeouts) GetEndpointVerification() time.Duration {
+	if t == nil || t.EndpointVerification == nil {
+		return DefaultEndpointVerificationTimeout
+	}
+	return t.EndpointVerification.Duration
+}
+
+// GetCertificateIssuance returns the CertificateIssuance timeout, or its default if it is not set
+func (t *Timeouts) GetCertificateIssuance() time.Duration {
+	if t == nil || t.CertificateIssuance == nil {
+		return DefaultCertificateIssuanceTimeout
+	}
+	return t.CertificateIssuance.Duration
+}
+
+type KeyAlgorithm string
+
+const (
+	KeyAlgorithmRSA   KeyAlgorithm = "RSA"
+	KeyAlgorithmECDSA KeyAlgorithm = "ECDSA"
+)
+
+type CertificateGeneration struct {
+	// KeyAlgorithm is the algorithm of the private keys (RSA or ECDSA). Defaults to RSA.
+	//+kubebuilder:validation:Enum=RSA;ECDSA
+	//+kubebuilder:default=RSA
+	KeyAlgorithm KeyAlgorithm `json:"keyAlgorithm,omitempty"`
+
+	// KeySize is the size of the RSA keys in bits (2048, 3072 or 4096), or the size of the ECDSA curve (256 for P-256 or 384 for P-384).
+	// Defaults to 2048 for RSA, and 256 for ECDSA.
+	KeySize *int32 `json:"keySize,omitempty"`
+
+	// Validity is how long the certificates are valid for. Defaults to 2 years (17520h).
+	//+kubebuilder:default="17520h0m0s"
+	Validity *metav1.Duration `json:"validity,omitempty"`
+
+	// RenewBefore is how long before their expiry the certificates are generated again. It must be shorter than the Validity. Defaults to 30 days (720h).
+	//+kubebuilder:default="720h0m0s"
+	RenewBefore *metav1.Duration `json:"renewBefore,omitempty"`
+
+	// APIAdditionalNames are added to the subject alternative names of the API server certificate, in addition to api.<domain>.
+	// They can be DNS names (e.g. api-int.<domain>) or IP addresses.
+	APIAdditionalNames []string `json:"apiAdditionalNames,omitempty"`
+
+	// IngressAdditionalNames are added to the subject alternative names of the ingress certificate, in addition to *.apps.<domain>.
+	// They can be DNS names (e.g. an application hostname outside of *.apps.<domain>) or IP addresses.
+	IngressAdditionalNames []string `json:"ingressAdditionalNames,omitempty"`
+
+	// CA selects the CA which signs the generated certificates. Defaults to loadbalancer-serving-signer.
+	CA *CertificateAuthority `json:"ca,omitempty"`
+}
+
+type CAType string
+
+const (
+	// CATypeLoadBalancerServingSigner signs the certificates with openshift-kube-apiserver-operator/loadbalancer-serving-signer,
+	// which is managed and rotated by the cluster.
+	CATypeLoadBalancerServingSigner CAType = "LoadBalancerServingSigner"
+
+	// CATypeManaged signs the certificates with a CA which is created by the operator, and kept in the relocation-ca secret of openshift-config.
+	CATypeManaged CAType = "Managed"
+
+	// CATypeUser signs the certificates with the CA of SecretRef.
+	CATypeUser CAType = "User"
+)
+
+type CertificateAuthority struct {
+	// Type is the CA which signs the generated certificates (LoadBalancerServingSigner, Managed or User). Defaults to LoadBalancerServingSigner.
+	// The Managed and User CAs are published in the relocation-ca ConfigMap of openshift-config, and added to the trusted CA bundle of the cluster proxy.
+	//+kubebuilder:validation:Enum=LoadBalancerServingSigner;Managed;User
+	//+kubebuilder:default=LoadBalancerServingSigner
+	Type CAType `json:"type,omitempty"`
+
+	// SecretRef is a reference to a TLS secret with the certificate and the private key of the CA, when the Type is User.
+	// The type of the secret must be kubernetes.io/tls.
+	SecretRef *corev1.SecretReference `json:"secretRef,omitempty"`
+}
+
+const (
+	DefaultRSAKeySize             int32 = 2048
+	DefaultECDSAKeySize           int32 = 256
+	DefaultCertificateValidity          = 2 * 365 * 24 * time.Hour
+	DefaultCertificateRenewBefore       = 30 * 24 * time.Hour
+)
+
+// GetKeyAlgorithm returns the KeyAlgorithm, or its default if it is not set
+func (g *CertificateGeneration) GetKeyAlgorithm() KeyAlgorithm {
+	if g == nil || g.KeyAlgorithm == "" {
+		return KeyAlgorithmRSA
+	}
+	return g.KeyAlgorithm
+}
+
+// GetKeySize returns the KeySize, or the default for the KeyAlgorithm if it is not set
+func (g *CertificateGeneration) GetKeySize() int32 {
+	if g != nil && g.KeySize != nil {
+		return *g.KeySize
+	}
+	if g.GetKeyAlgorithm() == KeyAlgorithmECDSA {
+		return DefaultECDSAKeySize
+	}
+	return DefaultRSAKeySize
+}
+
+// GetValidity returns the Validity, or its default if it is not set
+func (g *CertificateGeneration) GetValidity() time.Duration {
+	if g == nil || g.Validity == nil {
+		return DefaultCertificateValidity
+	}
+	return g.Validity.Duration
+}
+
+// GetRenewBefore returns the RenewBefore, or its default if it is not set
+func (g *CertificateGeneration) GetRenewBefore() time.Duration {
+	if g == nil || g.RenewBefore == nil {
+		return DefaultCertificateRenewBefore
+	}
+	return g.RenewBefore.Duration
+}
+
+// GetCAType returns the type of the CA, or its default if it is not set
+func (g *CertificateGeneration) GetCAType() CAType {
+	if g == nil || g.CA == nil || g.CA.Type == "" {
+		return CATypeLoadBalancerServingSigner
+	}
+	return g.CA.Type
+}
+
+// GetAPIAdditionalNames returns the APIAdditionalNames, or nil if they are not set
+func (g *CertificateGeneration) GetAPIAdditionalNames() []string {
+	if g == nil {
+		return nil
+	}
+	return g.APIAdditionalNames
+}
+
+// GetIngressAdditionalNames returns the IngressAdditionalNames, or nil if they are not set
+func (g *CertificateGeneration) GetIngressAdditionalNames() []string {
+	if g == nil {
+		return nil
+	}
+	return g.IngressAdditionalNames
+}
+
+type CertManager struct {
+	// IssuerRef is the cert-manager issuer which issues the certificates.
+	IssuerRef CertManagerIssuerReference `json:"issuerRef"`
+
+	// Namespace is where the Certificates are created, along with the secrets that cert-manager stores the certificates in.
+	// When the issuer is an Issuer, it must be in this namespace. Defaults to openshift-config.
+	//+kubebuilder:default=openshift-config
+	Namespace string `json:"namespace,omitempty"`
+}
+
+type CertManagerIssuerReference struct {
+	// Name is the name of the issuer.
+	Name string `json:"name"`
+
+	// Kind is the kind of the issuer. It must be Issuer or ClusterIssuer when the Group is cert-manager.io. Defaults to Issuer.
+	//+kubebuilder:default=Issuer
+	Kind string `json:"kind,omitempty"`
+
+	// Group is the API group of the issuer, which is only different from cert-manager.io for external issuers. Defaults to cert-manager.io.
+	//+kubebuilder:default=cert-manager.io
+	Group string `json:"group,omitempty"`
+}
+
+const (
+	CertManagerGroup             = "cert-manager.io"
+	CertManagerIssuerKind        = "Issuer"
+	CertManagerClusterIssuerKind = "ClusterIssuer"
+)
+
+// GetNamespace returns the Namespace, or its default if it is not set
+func (m *CertManager) GetNamespace() string {
+	if m == nil || m.Namespace == "" {
+		return ConfigNamespace
+	}
+	return m.Namespace
+}
+
+// GetKind returns the Kind, or its default if it is not set
+func (r *CertManagerIssuerReference) GetKind() string {
+	if r.Kind == "" {
+		return CertManagerIssuerKind
+	}
+	return r.Kind
+}
+
+// GetGroup returns the Group, or its default if it is not set
+func (r *CertManagerIssuerReference) GetGroup() string {
+	if r.Group == "" {
+		return CertManagerGroup
+	}
+	return r.Group
+}
+
+type IngressController struct {
+	// Name is the name of the IngressController, in the openshift-ingress-operator namespace.
+	Name string `json:"name"`
+
+	// Domain is the new domain of the Routes served by the IngressController. The certificate of the IngressController is for *.<domain>.
+	// The domain of an IngressController can't be changed, so the hostnames of its Routes are rewritten in place with this domain, whatever the RouteReset policy.
+	Domain string `json:"domain"`
+
+	// CertRef is a reference to a TLS secret that will be used as the default certificate of the IngressController.
+	// If it is omitted, the certificate is requested from cert-manager when CertManager is set, otherwise it will be generated in the same way as the ingress certificate.
+	// The type of the secret must be kubernetes.io/tls.
+	CertRef *corev1.SecretReference `json:"certRef,omitempty"`
+
+	// RouteSelector restricts the Routes which are re-created with the new domain to the ones with matching labels.
+	// By default, every Route admitted by the IngressController is re-created, unless its hostname is already in the new domain.
+	RouteSelector *metav1.LabelSelector `json:"routeSelector,omitempty"`
+}
+
+type ComponentRoute struct {
+	// Name is the name of the component route.
+	Name string `json:"name"`
+
+	// Namespace is the namespace of the component route.
+	Namespace string `json:"namespace"`
+
+	// Hostname is the new hostname of the route. It defaults to the hostname in the new domain that the operator sets for the console, downloads and oauth-openshift routes,
+	// or to <name>-<namespace>.apps.<domain> for the other routes.
+	Hostname string `json:"hostname,omitempty"`
+
+	// CertRef is a reference to a TLS secret that will be served for the route, which must be valid for its hostname.
+	// If it is omitted, the ingress certificate is served, so the hostname must be covered by the ingress certificate.
+	// The type of the secret must be kubernetes.io/tls.
+	CertRef *corev1.SecretReference `json:"certRef,omitempty"`
+}
+
+type RouteResetPolicy string
+
+const (
+	// RouteResetPolicyDelete deletes the Routes of the default IngressController whose hostname was generated, so that they are re-created by their owners with a hostname in the new domain.
+	// The hostname of the Routes with an explicit spec.host is rewritten instead, since they would not be re-created in the new domain.
+	RouteResetPolicyDelete RouteResetPolicy = "Delete"
+
+	// RouteResetPolicyRewriteHost rewrites the spec.host of the Routes with the new domain, in place.
+	RouteResetPolicyRewriteHost RouteResetPolicy = "RewriteHost"
+)
+
+// DefaultRouteResetExclusions are the namespaces whose Routes are not reset by default.
+// The console and authentication Routes are moved with the componentRoutes of the cluster Ingress,
+// and the Klusterlet add-on ignores the appsDomain of the cluster Ingress, so its Route would always be re-created with the original domain.
+var DefaultRouteResetExclusions = []string{"openshift-console", "openshift-authentication", "open-cluster-management-agent-addon"}
+
+type RouteReset struct {
+	// Policy defines whether the Routes are deleted, or rewritten in place. Defaults to 'Delete'.
+	//+kubebuilder:validation:Enum=Delete;RewriteHost
+	//+kubebuilder:default=Delete
+	Policy RouteResetPolicy `json:"policy,omitempty"`
+
+	// NamespaceSelector restricts the Routes which are reset to the ones in namespaces with matching labels.
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// RouteSelector restricts the Routes which are reset to the ones with matching labels.
+	RouteSelector *metav1.LabelSelector `json:"routeSelector,omitempty"`
+
+	// Exclusions are the Routes which are never reset, given as <namespace> for every Route of a namespace, or as <namespace>/<name> for a single Route.
+	// Defaults to the openshift-console, openshift-authentication and open-cluster-management-agent-addon namespaces.
+	Exclusions []string `json:"exclusions,omitempty"`
+}
+
+// GetPolicy returns the Policy, or its default if it is not set
+func (r *RouteReset) GetPolicy() RouteResetPolicy {
+	if r == nil || r.Policy == "" {
+		return RouteResetPolicyDelete
+	}
+	return r.Policy
+}
+
+// GetExclusions returns the Exclusions, or the DefaultRouteResetExclusions if they are not set
+func (r *RouteReset) GetExclusions() []string {
+	if r == nil || r.Exclusions == nil {
+		return DefaultRouteResetExclusions
+	}
+	return r.Exclusions
+}
+
+type API struct {
+	// AdditionalNames are other hostnames that the API server is served on (e.g. aliases of api.<domain>).
+	// They are added to the certificate and to the named certificate of the API server, verified once the API server has been reconfigured,
+	// and registered in ACM. They must resolve to the API server.
+	AdditionalNames []string `json:"additionalNames,omitempty"`
+
+	// KeepOriginalDomain serves api.<original domain> with the certificate of the relocated domain as well,
+	// so that the clients which still use the original domain can keep using it during the migration. Defaults to false.
+	//+kubebuilder:default=false
+	KeepOriginalDomain *bool `json:"keepOriginalDomain,omitempty"`
+}
+
+// GetNetworkManagerDNSMasq returns NetworkManagerDNSMasq, or its default if it is not set
+func (d *DNS) GetNetworkManagerDNSMasq() bool {
+	if d == nil || d.NetworkManagerDNSMasq == nil {
+		return false
+	}
+	return *d.NetworkManagerDNSMasq
+}
+
+// GetAdditionalNames returns the AdditionalNames, or nil if they are not set
+func (a *API) GetAdditionalNames() []string {
+	if a == nil {
+		return nil
+	}
+	return a.AdditionalNames
+}
+
+// GetKeepOriginalDomain returns KeepOriginalDomain, or its default if it is not set
+func (a *API) GetKeepOriginalDomain() bool {
+	if a == nil || a.KeepOriginalDomain == nil {
+		return false
+	}
+	return *a.KeepOriginalDomain
+}
+
+type Verification struct {
+	// APIPort is the port that the API server is verified on. Defaults to 6443.
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:validation:Maximum=65535
+	//+kubebuilder:default=6443
+	APIPort *int32 `json:"apiPort,omitempty"`
+
+	// IngressPort is the HTTPS port that the ingress is verified on. Defaults to 443.
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:validation:Maximum=65535
+	//+kubebuilder:default=443
+	IngressPort *int32 `json:"ingressPort,omitempty"`
+}
+
+const (
+	DefaultAPIPort     int32 = 6443
+	DefaultIngressPort int32 = 443
+)
+
+// GetAPIPort returns the APIPort, or its default if it is not set
+func (v *Verification) GetAPIPort() int32 {
+	if v == nil || v.APIPort == nil {
+		return DefaultAPIPort
+	}
+	return *v.APIPort
+}
+
+// GetIngressPort returns the IngressPort, or its default if it is not set
+func (v *Verification) GetIngressPort() int32 {
+	if v == nil || v.IngressPort == nil {
+		return DefaultIngressPort
+	}
+	return *v.IngressPort
+}
+
+type RelocationMode string
+
+const (
+	// ModeApply applies the relocation to the cluster.
+	ModeApply RelocationMode = "Apply"
+
+	// ModePlan reports the changes that the relocation would make, without applying them.
+	ModePlan RelocationMode = "Plan"
+)
+
+type PlanStatus struct {
+	// ObservedGeneration is the generation of the ClusterRelocation that the plan was computed for.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Steps lists the changes that each step of the relocation would make.
+	//+listType=map
+	//+listMapKey=name
+	Steps []PlannedStep `json:"steps,omitempty"`
+}
+
+type PlannedStep struct {
+	// Name is the name of the step.
+	Name string `json:"name"`
+
+	// Changes are the objects that the step would create, modify or delete.
+	Changes []PlannedChange `json:"changes,omitempty"`
+
+	// WaitingFor describes what the step would wait for before it completes.
+	// The changes made by the step after the wait are not part of the plan.
+	WaitingFor string `json:"waitingFor,omitempty"`
+
+	// Error is the error returned by the step while it was planned.
+	// The changes made by the step after the error are not part of the plan.
+	Error string `json:"error,omitempty"`
+}
+
+type PlannedChange struct {
+	// Operation is the operation that would be performed on the object (Create, Update, Patch or Delete).
+	Operation string `json:"operation"`
+
+	// APIVersion is the API version of the object.
+	APIVersion string `json:"apiVersion"`
+
+	// Kind is the kind of the object.
+	Kind string `json:"kind"`
+
+	// Namespace is the namespace of the object, if it is namespaced.
+	Namespace string `json:"namespace,omitempty"`
+
+	// Name is the name of the object.
+	Name string `json:"name"`
+
+	// Fields are the paths of the fields that would be modified by an Update or a Patch, e.g. spec.servingCerts.namedCertificates.
+	// The values are not reported, so that the content of Secrets is not exposed.
+	Fields []string `json:"fields,omitempty"`
+
+	// Remote is true if the object is on the ACM hub cluster, rather than on this cluster.
+	Remote bool `json:"remote,omitempty"`
+}
+
+type DNSLookupResult string
+
+const (
+	// DNSLookupResolved means that the name resolved to the expected addresses.
+	DNSLookupResolved DNSLookupResult = "Resolved"
+
+	// DNSLookupMismatch means that the name resolved, but to unexpected addresses.
+	DNSLookupMismatch DNSLookupResult = "Mismatch"
+
+	// DNSLookupFailed means that the name didn't resolve.
+	DNSLookupFailed DNSLookupResult = "Failed"
+)
+
+type DNSLookup struct {
+	// Name is the name which was looked up.
+	Name string `json:"name"`
+
+	// Nameserver is the DNS server which was queried. It is empty if the resolver of the operator was used.
+	Nameserver string `json:"nameserver,omitempty"`
+
+	// Result is the result of the lookup.
+	//+kubebuilder:validation:Enum=Resolved;Mismatch;Failed
+	Result DNSLookupResult `json:"result"`
+
+	// Addresses are the addresses that the name resolved to.
+	Addresses []string `json:"addresses,omitempty"`
+
+	// Expected are the addresses that the name is expected to resolve to. It is empty if any address is accepted.
+	Expected []string `json:"expected,omitempty"`
+
+	// Error is the error returned by the lookup, or the reason for a mismatch.
+	Error string `json:"error,omitempty"`
+
+	// Optional is true if the result of the lookup doesn't block the relocation.
+	Optional bool `json:"optional,omitempty"`
+}
+
+// The names of the verified endpoints
+const (
+	EndpointAPI     = "API"
+	EndpointIngress = "Ingress"
+)
+
+type EndpointStatus struct {
+	// Name is the name of the endpoint (API or Ingress).
+	Name string `json:"name"`
+
+	// Address is the address that the endpoint was verified on, as <hostname>:<port>.
+	Address string `json:"address"`
+
+	// Verified is true if the endpoint serves a certificate which is valid for its hostname, and signed by the expected CA.
+	Verified bool `json:"verified"`
+
+	// DNSNames are the subject alternative names of the certificate served by the endpoint.
+	DNSNames []string `json:"dnsNames,omitempty"`
+
+	// Issuer is the issuer of the certificate served by the endpoint.
+	Issuer string `json:"issuer,omitempty"`
+
+	// NotAfter is the expiry time of the certificate served by the endpoint.
+	NotAfter *metav1.Time `json:"notAfter,omitempty"`
+
+	// Error describes why the endpoint could not be verified.
+	Error string `json:"error,omitempty"`
+}
+
+// The names of the generated certificates
+const (
+	CertificateAPI     = "API"
+	CertificateIngress = "Ingress"
+)
+
+type CertificateStatus struct {
+	// Name is the name of the certificate (API or Ingress).
+	Name string `json:"name"`
+
+	// SecretRef is the secret which holds the certificate.
+	SecretRef corev1.SecretReference `json:"secretRef"`
+
+	// NotAfter is the expiry time of the certificate.
+	NotAfter metav1.Time `json:"notAfter"`
+
+	// RenewalTime is the time at which the certificate will be generated again.
+	RenewalTime metav1.Time `json:"renewalTime"`
+}
+
+type RouteAction string
+
+const (
+	// RouteActionDeleted means that the Route was deleted, so that it is re-created by its owner.
+	RouteActionDeleted RouteAction = "Deleted"
+
+	// RouteActionHostRewritten means that the spec.host of the Route was rewritten with the new domain.
+	RouteActionHostRewritten RouteAction = "HostRewritten"
+)
+
+type RouteStatus struct {
+	// Namespace is the namespace of the Route.
+	Namespace string `json:"namespace"`
+
+	// Name is the name of the Route.
+	Name string `json:"name"`
+
+	// RouterName is the name of the IngressController which admitted the Route.
+	RouterName string `json:"routerName"`
+
+	// Action is what was done to the Route (Deleted or HostRewritten).
+	Action RouteAction `json:"action"`
+
+	// OriginalHost is the hostname of the Route before it was first deleted or rewritten.
+	OriginalHost string `json:"originalHost"`
+
+	// Host is the hostname that the Route was rewritten with.
+	Host string `json:"host,omitempty"`
+}
+
+type StepPhase string
+
+const (
+	// StepPhaseRunning means that the step is being applied.
+	StepPhaseRunning StepPhase = "Running"
+
+	// StepPhaseWaiting means that the step has been applied,
+	// and is waiting for the cluster to converge. It is checked again periodically.
+	StepPhaseWaiting StepPhase = "Waiting"
+
+	// StepPhaseCompleted means that the step has completed for the current generation.
+	StepPhaseCompleted StepPhase = "Completed"
+
+	// StepPhaseFailed means that the step returned an error. It is retried with a backoff.
+	StepPhaseFailed StepPhase = "Failed"
+)
+
+type StepStatus struct {
+	// Name is the name of the step.
+	Name string `json:"name"`
+
+	// Phase is the current phase of the step.
+	//+kubebuilder:validation:Enum=Running;Waiting;Completed;Failed
+	Phase StepPhase `json:"phase,omitempty"`
+
+	// WaitingFor describes what the step is waiting for, while it is in the Waiting phase.
+	WaitingFor string `json:"waitingFor,omitempty"`
+
+	// WaitingSince is the time at which the step started waiting. It isn't reset when WaitingFor changes, until the step completes.
+	WaitingSince *metav1.Time `json:"waitingSince,omitempty"`
+
+	// StartTime is the time at which the step started running for the current generation.
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is the time at which the step completed successfully.
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// LastError is the error returned by the most recent failed run of the step.
+	// It is cleared once the step succeeds.
+	LastError string `json:"lastError,omitempty"`
+
+	// ObservedGeneration is the generation of the ClusterRelocation that the step last ran against.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+type ACMRegistration struct {
+	// URL is the API URL of the ACM cluster.
+	URL string `json:"url"`
+
+	// ClusterName will be the name of the ManagedCluster in ACM.
+	ClusterName string `json:"clusterName"`
+
+	// ManagedClusterSet is the ManagedClusterSet that the ManagedCluster will join. Defaults to 'default'.
+	//+kubebuilder:default=default
+	ManagedClusterSet *string `json:"managedClusterSet,omitempty"`
+
+	// acmSecret is a secret reference with credentials for the ACM cluster.
+	// It must have a 'token' field. Optionally, it can have a 'ca.crt' field
+	// which provides the CA bundle for the ACM cluster.
+	// The secret is deleted once ACM registration succeeds.
+	// The type of the secret must be Opaque.
+	ACMSecret corev1.SecretReference `json:"acmSecret"`
+
+	// KlusterletAddonConfig is the klusterlet add-on configuration.
+	KlusterletAddonConfig *agentv1.KlusterletAddonConfigSpec `json:"klusterletAddonConfig,omitempty"`
+}
+
+const (
+	ConditionTypeReady      string = "Ready"
+	ConditionTypeReconciled string = "Reconciled"
+	ConditionTypePlanned    string = "Planned"
+
+	// Per-step conditions
+	ConditionTypeDNSReady          string = "DNSReady"
+	ConditionTypeSSHReady          string = "SSHReady"
+	ConditionTypeRegistryCertReady string = "RegistryCertReady"
+	ConditionTypeMirrorReady       string = "MirrorReady"
+	ConditionTypePullSecretReady   string = "PullSecretReady"
+	ConditionTypeCatalogReady      string = "CatalogReady"
+	ConditionTypeCAReady           string = "CAReady"
+	ConditionTypeIngressReady      string = "IngressReady"
+	ConditionTypeAPIReady          string = "APIReady"
+	ConditionTypeACMRegistered     string = "ACMRegistered"
+)
+
+const (
+	StepDNS          string = "DNS"
+	StepSSH          string = "SSH"
+	StepRegistryCert string = "RegistryCert"
+	StepMirror       string = "Mirror"
+	StepPullSecret   string = "PullSecret"
+	StepCatalog      string = "Catalog"
+	StepCA           string = "CA"
+	StepIngress      string = "Ingress"
+	StepAPI          string = "API"
+	StepACM          string = "ACM"
+)
+
+const (
+	PullSecretName       string = "pull-secret"
+	BackupPullSecretName string = "backup-pull-secret"
+	ConfigNamespace      string = "openshift-config"
+	IngressNamespace     string = "openshift-ingress"
+
+	IngressOperatorNamespace string = "openshift-ingress-operator"
+	DefaultIngressController string = "default"
+)
+
+const (
+	// ValidationSucceededReason represents the fact that the validation of
+	// the resource has succeeded.
+	ValidationSucceededReason string = "ValidationSucceeded"
+
+	// ValidationFailedReason represents the fact that the validation of
+	// the resource has failed.
+	ValidationFailedReason string = "ValidationFailed"
+
+	// ReconciliationSucceededReason represents the fact that the validation of
+	// the resource has succeeded.
+	ReconciliationSucceededReason string = "ReconciliationSucceeded"
+
+	// StepInProgressReason represents the fact that a step has started,
+	// but has not yet completed.
+	StepInProgressReason string = "StepInProgress"
+
+	// StepWaitingReason represents the fact that a step is waiting
+	// for the cluster to converge.
+	StepWaitingReason string = "StepWaiting"
+
+	// TimedOutReason represents the fact that a step has waited
+	// longer than its timeout for the cluster to converge.
+	TimedOutReason string = "TimedOut"
+
+	// InvalidCertificateReason represents the fact that a step has failed,
+	// because a certificate can't be served for the new domain (e.g. it is expired, or its private key doesn't match).
+	InvalidCertificateReason string = "InvalidCertificate"
+
+	// StepNotConfiguredReason represents the fact that a step was skipped,
+	// because it is not configured in the spec.
+	StepNotConfiguredReason string = "StepNotConfigured"
+
+	// PlanModeReason represents the fact that the relocation was not applied,
+	// because the ClusterRelocation is in Plan mode.
+	PlanModeReason string = "PlanMode"
+
+	// PlanCompleteReason represents the fact that every step was planned.
+	PlanCompleteReason string = "PlanComplete"
+
+	// PlanIncompleteReason represents the fact that some steps could not be fully planned,
+	// because they returned an error, or would have waited for the cluster to converge.
+	PlanIncompleteReason string = "PlanIncomplete"
+
+	APIReconciliationFailedReason        string = "APIReconciliationFailed"
+	IngressReconciliationFailedReason    string = "IngressReconciliationFailed"
+	PullSecretReconciliationFailedReason string = "PullSecretReconciliationFailed"
+	SSHReconciliationFailedReason        string = "SSHReconciliationFailed"
+	RegistryReconciliationFailedReason   string = "RegistryReconciliationFailed"
+	MirrorReconciliationFailedReason     string = "MirrorReconciliationFailed"
+	CatalogReconciliationFailedReason    string = "CatalogReconciliationFailed"
+	CAReconciliationFailedReason         string = "CAReconciliationFailed"
+	DNSReconciliationFailedReason        string = "DNSReconciliationFailed"
+	ACMReconciliationFailedReason        string = "ACMReconciliationFailed"
+	InProgressReconciliationFailedReason string = "ReconcileInProgress"
+)
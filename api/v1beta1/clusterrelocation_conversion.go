@@ -0,0 +1,146 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"encoding/json"
+
+	v1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+// ConversionDataAnnotation holds the v1 spec of a ClusterRelocation which was read as v1beta1,
+// when the spec has fields that v1beta1 can't represent (e.g. more than one registry certificate).
+// It is used to restore these fields when the ClusterRelocation is converted back to v1.
+const ConversionDataAnnotation = "rhsyseng.github.io/v1-spec"
+
+var _ conversion.Convertible = &ClusterRelocation{}
+
+// ConvertTo converts this ClusterRelocation to the Hub version (v1)
+func (src *ClusterRelocation) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1.ClusterRelocation)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	if err := convertSpecTo(&src.Spec, &dst.Spec); err != nil {
+		return err
+	}
+	if err := convertJSON(&src.Status, &dst.Status); err != nil {
+		return err
+	}
+
+	data, ok := dst.Annotations[ConversionDataAnnotation]
+	if !ok {
+		return nil
+	}
+	delete(dst.Annotations, ConversionDataAnnotation)
+	if len(dst.Annotations) == 0 {
+		dst.Annotations = nil
+	}
+
+	// the saved fields are only restored if the spec wasn't modified through v1beta1 in the meantime,
+	// since they may no longer be consistent with the rest of the spec
+	saved := v1.ClusterRelocationSpec{}
+	if err := json.Unmarshal([]byte(data), &saved); err != nil {
+		// the annotation was modified, so it can't be trusted
+		return nil
+	}
+	savedSpoke := ClusterRelocationSpec{}
+	if err := convertSpecFrom(&saved, &savedSpoke); err != nil {
+		return err
+	}
+	if equality.Semantic.DeepEqual(savedSpoke, src.Spec) {
+		dst.Spec = saved
+	}
+	return nil
+}
+
+// ConvertFrom converts from the Hub version (v1) to this version
+func (dst *ClusterRelocation) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1.ClusterRelocation)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	if err := convertSpecFrom(&src.Spec, &dst.Spec); err != nil {
+		return err
+	}
+	if err := convertJSON(&src.Status, &dst.Status); err != nil {
+		return err
+	}
+
+	delete(dst.Annotations, ConversionDataAnnotation)
+	roundTrip := v1.ClusterRelocationSpec{}
+	if err := convertSpecTo(&dst.Spec, &roundTrip); err != nil {
+		return err
+	}
+	if equality.Semantic.DeepEqual(roundTrip, src.Spec) {
+		// nothing was lost
+		return nil
+	}
+	data, err := json.Marshal(&src.Spec)
+	if err != nil {
+		return err
+	}
+	if dst.Annotations == nil {
+		dst.Annotations = map[string]string{}
+	}
+	dst.Annotations[ConversionDataAnnotation] = string(data)
+	return nil
+}
+
+func convertSpecTo(src *ClusterRelocationSpec, dst *v1.ClusterRelocationSpec) error {
+	// the fields that are the same in both versions are copied through their JSON representation
+	if err := convertJSON(src, dst); err != nil {
+		return err
+	}
+	if src.RegistryCert != nil {
+		registryCert := v1.RegistryCert{}
+		if err := convertJSON(src.RegistryCert, &registryCert); err != nil {
+			return err
+		}
+		dst.RegistryCerts = []v1.RegistryCert{registryCert}
+	}
+	if len(src.SSHKeys) > 0 {
+		// v1beta1 always added the keys to the master and worker pools, which is the default in v1
+		dst.SSH = &v1.SSH{AuthorizedKeys: append([]string(nil), src.SSHKeys...)}
+	}
+	return nil
+}
+
+func convertSpecFrom(src *v1.ClusterRelocationSpec, dst *ClusterRelocationSpec) error {
+	if err := convertJSON(src, dst); err != nil {
+		return err
+	}
+	if len(src.RegistryCerts) > 0 {
+		// v1beta1 only has room for one registry, the others are kept in the ConversionDataAnnotation
+		dst.RegistryCert = &RegistryCert{}
+		if err := convertJSON(&src.RegistryCerts[0], dst.RegistryCert); err != nil {
+			return err
+		}
+	}
+	if src.SSH != nil {
+		dst.SSHKeys = append([]string(nil), src.SSH.AuthorizedKeys...)
+	}
+	return nil
+}
+
+// convertJSON copies in to out through their JSON representation.
+// Fields which don't exist in out are dropped.
+func convertJSON(in interface{}, out interface{}) error {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"math/rand"
+	"testing"
+
+	v1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/api/apitesting/fuzzer"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metafuzzer "k8s.io/apimachinery/pkg/apis/meta/fuzzer"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+)
+
+const fuzzIterations = 1000
+
+func newFuzzer(t *testing.T) func(obj interface{}) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(AddToScheme(scheme))
+	utilruntime.Must(v1.AddToScheme(scheme))
+
+	seed := rand.Int63()
+	t.Logf("fuzzer seed: %d", seed)
+	f := fuzzer.FuzzerFor(metafuzzer.Funcs, rand.NewSource(seed), serializer.NewCodecFactory(scheme))
+	return func(obj interface{}) { f.Fuzz(obj) }
+}
+
+// v1beta1 -> v1 -> v1beta1 must not lose anything, since every v1beta1 field has a v1 equivalent
+func TestSpokeHubSpokeRoundTrip(t *testing.T) {
+	fuzz := newFuzzer(t)
+	for i := 0; i < fuzzIterations; i++ {
+		spoke := &ClusterRelocation{}
+		fuzz(spoke)
+
+		hub := &v1.ClusterRelocation{}
+		if err := spoke.ConvertTo(hub); err != nil {
+			t.Fatalf("failed to convert v1beta1 to v1: %v", err)
+		}
+		result := &ClusterRelocation{}
+		if err := result.ConvertFrom(hub); err != nil {
+			t.Fatalf("failed to convert v1 to v1beta1: %v", err)
+		}
+
+		if !equality.Semantic.DeepEqual(spoke, result) {
+			t.Fatalf("v1beta1 -> v1 -> v1beta1 is not lossless (-want +got):\n%s", cmp.Diff(spoke, result))
+		}
+	}
+}
+
+// v1 -> v1beta1 -> v1 must not lose anything either, the fields that v1beta1 can't represent are kept in an annotation
+func TestHubSpokeHubRoundTrip(t *testing.T) {
+	fuzz := newFuzzer(t)
+	for i := 0; i < fuzzIterations; i++ {
+		hub := &v1.ClusterRelocation{}
+		fuzz(hub)
+
+		spoke := &ClusterRelocation{}
+		if err := spoke.ConvertFrom(hub); err != nil {
+			t.Fatalf("failed to convert v1 to v1beta1: %v", err)
+		}
+		result := &v1.ClusterRelocation{}
+		if err := spoke.ConvertTo(result); err != nil {
+			t.Fatalf("failed to convert v1beta1 to v1: %v", err)
+		}
+
+		if !equality.Semantic.DeepEqual(hub, result) {
+			t.Fatalf("v1 -> v1beta1 -> v1 is not lossless (-want +got):\n%s", cmp.Diff(hub, result))
+		}
+	}
+}
+
+// the v1 fields kept in the annotation must not override changes made through v1beta1
+func TestModifiedSpokeDiscardsSavedFields(t *testing.T) {
+	hub := &v1.ClusterRelocation{
+		Spec: v1.ClusterRelocationSpec{
+			Domain: "old.example.com",
+			RegistryCerts: []v1.RegistryCert{
+				{RegistryHostname: "registry1.example.com", Certificate: "cert1"},
+				{RegistryHostname: "registry2.example.com", Certificate: "cert2"},
+			},
+		},
+	}
+	spoke := &ClusterRelocation{}
+	if err := spoke.ConvertFrom(hub); err != nil {
+		t.Fatalf("failed to convert v1 to v1beta1: %v", err)
+	}
+	if _, ok := spoke.Annotations[ConversionDataAnnotation]; !ok {
+		t.Fatalf("expected the %s annotation to be set", ConversionDataAnnotation)
+	}
+
+	spoke.Spec.RegistryCert.Certificate = "cert3"
+	result := &v1.ClusterRelocation{}
+	if err := spoke.ConvertTo(result); err != nil {
+		t.Fatalf("failed to convert v1beta1 to v1: %v", err)
+	}
+
+	expected := []v1.RegistryCert{{RegistryHostname: "registry1.example.com", Certificate: "cert3"}}
+	if !equality.Semantic.DeepEqual(expected, result.Spec.RegistryCerts) {
+		t.Fatalf("unexpected registry certificates (-want +got):\n%s", cmp.Diff(expected, result.Spec.RegistryCerts))
+	}
+	if _, ok := result.Annotations[ConversionDataAnnotation]; ok {
+		t.Fatalf("expected the %s annotation to be removed", ConversionDataAnnotation)
+	}
+}
@@ -34,6 +34,8 @@ type ClusterRelocationSpec struct {
 	// AddInternalDNSEntries deploys a MachineConfig which adds api and *.apps entries for the new domain to dnsmasq on SNO clusters.
 	// Setting this to true will cause a reboot.
 	// If you don't enable this option, you need to make sure that the cluster can resolve the new domain address via some other method.
+	// Defaults to false.
+	//+kubebuilder:default=false
 	//+operator-sdk:csv:customresourcedefinitions:type=spec
 	AddInternalDNSEntries *bool `json:"addInternalDNSEntries,omitempty"`
 
@@ -61,6 +63,14 @@ type ClusterRelocationSpec struct {
 	//+operator-sdk:csv:customresourcedefinitions:type=spec
 	IngressCertRef *corev1.SecretReference `json:"ingressCertRef,omitempty"`
 
+	// Mode defines whether the relocation is applied to the cluster, or only planned. Defaults to 'Apply'.
+	// In Plan mode, the changes that the relocation would make are computed using dry-run requests and reported in status.plan.
+	// Nothing is applied to the cluster.
+	//+kubebuilder:validation:Enum=Apply;Plan
+	//+kubebuilder:default=Apply
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	Mode RelocationMode `json:"mode,omitempty"`
+
 	// PullSecretRef is a reference to new cluster-wide pull secret.
 	// If defined, it will replace the secret located at openshift-config/pull-secret.
 	// The type of the secret must be kubernetes.io/dockerconfigjson.
@@ -76,6 +86,10 @@ type ClusterRelocationSpec struct {
 	// If defined, it will be appended to the existing authorized SSH key(s).
 	//+operator-sdk:csv:customresourcedefinitions:type=spec
 	SSHKeys []string `json:"sshKeys,omitempty"`
+
+	// Timeouts defines how long the relocation waits for the cluster to converge before a step times out.
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	Timeouts *Timeouts `json:"timeouts,omitempty"`
 }
 
 // ClusterRelocationStatus defines the observed state of ClusterRelocation
@@ -85,11 +99,46 @@ type ClusterRelocationStatus struct {
 	// Conditions represent the latest available observations of an object's state
 	//+operator-sdk:csv:customresourcedefinitions:type=status
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Steps reports the progress of each step of the relocation.
+	//+listType=map
+	//+listMapKey=name
+	//+operator-sdk:csv:customresourcedefinitions:type=status
+	Steps []StepStatus `json:"steps,omitempty"`
+
+	// Plan reports the changes that the relocation would make to the cluster, when the mode is Plan.
+	//+operator-sdk:csv:customresourcedefinitions:type=status
+	Plan *PlanStatus `json:"plan,omitempty"`
+
+	// DNSLookups reports the results of the most recent preflight lookups of the new domain.
+	//+operator-sdk:csv:customresourcedefinitions:type=status
+	DNSLookups []DNSLookup `json:"dnsLookups,omitempty"`
+
+	// Endpoints reports the certificates served by the API server and the ingress, and whether they could be verified.
+	//+listType=map
+	//+listMapKey=name
+	//+operator-sdk:csv:customresourcedefinitions:type=status
+	Endpoints []EndpointStatus `json:"endpoints,omitempty"`
+
+	// Certificates reports the certificates generated by the operator, and when they will be renewed.
+	//+listType=map
+	//+listMapKey=name
+	//+operator-sdk:csv:customresourcedefinitions:type=status
+	Certificates []CertificateStatus `json:"certificates,omitempty"`
+
+	// Routes reports the Routes which were deleted or rewritten to move them to the new domain.
+	// The hostname of the rewritten Routes is restored when the CR is deleted.
+	//+listType=map
+	//+listMapKey=namespace
+	//+listMapKey=name
+	//+operator-sdk:csv:customresourcedefinitions:type=status
+	Routes []RouteStatus `json:"routes,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 //+kubebuilder:resource:scope=Cluster
+//+kubebuilder:deprecatedversion:warning="rhsyseng.github.io/v1beta1 ClusterRelocation is deprecated, use rhsyseng.github.io/v1 ClusterRelocation"
 
 // ClusterRelocation is the Schema for the clusterrelocations API
 // +operator-sdk:csv:customresourcedefinitions:resources={{Secret,v1,"generated-api-secret"},{Secret,v1,"generated-ingress-secret"}}
@@ -120,10 +169,15 @@ type CatalogSource struct {
 
 	// Image is an operator-registry container image to instantiate a registry-server with.
 	Image string `json:"image"`
+
+	// RegistryPollInterval is how often the CatalogSource polls its image for updates. Defaults to 24h.
+	//+kubebuilder:default="24h"
+	RegistryPollInterval string `json:"registryPollInterval,omitempty"`
 }
 
 type RegistryCert struct {
 	// RegistryHostname is the hostname of the new registry.
+	// If it is given as <hostname>:<port>, it is split into RegistryHostname and RegistryPort.
 	RegistryHostname string `json:"registryHostname"`
 
 	// RegistryPort is the port number that the registry is served on.
@@ -133,6 +187,243 @@ type RegistryCert struct {
 	Certificate string `json:"certificate"`
 }
 
+type Timeouts struct {
+	// ClusterOperatorSettle is how long to wait for a ClusterOperator to finish progressing, once it has been reconfigured. Defaults to 20m.
+	//+kubebuilder:default="20m0s"
+	ClusterOperatorSettle *metav1.Duration `json:"clusterOperatorSettle,omitempty"`
+
+	// MachineConfigPoolUpdate is how long to wait for a MachineConfigPool to apply a new MachineConfig. Defaults to 60m.
+	//+kubebuilder:default="1h0m0s"
+	MachineConfigPoolUpdate *metav1.Duration `json:"machineConfigPoolUpdate,omitempty"`
+
+	// ACMImport is how long to wait for the ACM import secret to become available. Defaults to 5m.
+	//+kubebuilder:default="5m0s"
+	ACMImport *metav1.Duration `json:"acmImport,omitempty"`
+
+	// KlusterletAvailable is how long to wait for the Klusterlet to become Available. Defaults to 5m.
+	//+kubebuilder:default="5m0s"
+	KlusterletAvailable *metav1.Duration `json:"klusterletAvailable,omitempty"`
+
+	// EndpointVerification is how long to wait for the API server and the ingress to serve the new certificates. Defaults to 30m.
+	//+kubebuilder:default="30m0s"
+	EndpointVerification *metav1.Duration `json:"endpointVerification,omitempty"`
+}
+
+type RelocationMode string
+
+const (
+	// ModeApply applies the relocation to the cluster.
+	ModeApply RelocationMode = "Apply"
+
+	// ModePlan reports the changes that the relocation would make, without applying them.
+	ModePlan RelocationMode = "Plan"
+)
+
+type PlanStatus struct {
+	// ObservedGeneration is the generation of the ClusterRelocation that the plan was computed for.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Steps lists the changes that each step of the relocation would make.
+	//+listType=map
+	//+listMapKey=name
+	Steps []PlannedStep `json:"steps,omitempty"`
+}
+
+type PlannedStep struct {
+	// Name is the name of the step.
+	Name string `json:"name"`
+
+	// Changes are the objects that the step would create, modify or delete.
+	Changes []PlannedChange `json:"changes,omitempty"`
+
+	// WaitingFor describes what the step would wait for before it completes.
+	// The changes made by the step after the wait are not part of the plan.
+	WaitingFor string `json:"waitingFor,omitempty"`
+
+	// Error is the error returned by the step while it was planned.
+	// The changes made by the step after the error are not part of the plan.
+	Error string `json:"error,omitempty"`
+}
+
+type PlannedChange struct {
+	// Operation is the operation that would be performed on the object (Create, Update, Patch or Delete).
+	Operation string `json:"operation"`
+
+	// APIVersion is the API version of the object.
+	APIVersion string `json:"apiVersion"`
+
+	// Kind is the kind of the object.
+	Kind string `json:"kind"`
+
+	// Namespace is the namespace of the object, if it is namespaced.
+	Namespace string `json:"namespace,omitempty"`
+
+	// Name is the name of the object.
+	Name string `json:"name"`
+
+	// Fields are the paths of the fields that would be modified by an Update or a Patch, e.g. spec.servingCerts.namedCertificates.
+	// The values are not reported, so that the content of Secrets is not exposed.
+	Fields []string `json:"fields,omitempty"`
+
+	// Remote is true if the object is on the ACM hub cluster, rather than on this cluster.
+	Remote bool `json:"remote,omitempty"`
+}
+
+type DNSLookupResult string
+
+const (
+	// DNSLookupResolved means that the name resolved to the expected addresses.
+	DNSLookupResolved DNSLookupResult = "Resolved"
+
+	// DNSLookupMismatch means that the name resolved, but to unexpected addresses.
+	DNSLookupMismatch DNSLookupResult = "Mismatch"
+
+	// DNSLookupFailed means that the name didn't resolve.
+	DNSLookupFailed DNSLookupResult = "Failed"
+)
+
+type DNSLookup struct {
+	// Name is the name which was looked up.
+	Name string `json:"name"`
+
+	// Nameserver is the DNS server which was queried. It is empty if the resolver of the operator was used.
+	Nameserver string `json:"nameserver,omitempty"`
+
+	// Result is the result of the lookup.
+	//+kubebuilder:validation:Enum=Resolved;Mismatch;Failed
+	Result DNSLookupResult `json:"result"`
+
+	// Addresses are the addresses that the name resolved to.
+	Addresses []string `json:"addresses,omitempty"`
+
+	// Expected are the addresses that the name is expected to resolve to. It is empty if any address is accepted.
+	Expected []string `json:"expected,omitempty"`
+
+	// Error is the error returned by the lookup, or the reason for a mismatch.
+	Error string `json:"error,omitempty"`
+
+	// Optional is true if the result of the lookup doesn't block the relocation.
+	Optional bool `json:"optional,omitempty"`
+}
+
+type EndpointStatus struct {
+	// Name is the name of the endpoint (API or Ingress).
+	Name string `json:"name"`
+
+	// Address is the address that the endpoint was verified on, as <hostname>:<port>.
+	Address string `json:"address"`
+
+	// Verified is true if the endpoint serves a certificate which is valid for its hostname, and signed by the expected CA.
+	Verified bool `json:"verified"`
+
+	// DNSNames are the subject alternative names of the certificate served by the endpoint.
+	DNSNames []string `json:"dnsNames,omitempty"`
+
+	// Issuer is the issuer of the certificate served by the endpoint.
+	Issuer string `json:"issuer,omitempty"`
+
+	// NotAfter is the expiry time of the certificate served by the endpoint.
+	NotAfter *metav1.Time `json:"notAfter,omitempty"`
+
+	// Error describes why the endpoint could not be verified.
+	Error string `json:"error,omitempty"`
+}
+
+// The names of the generated certificates
+const (
+	CertificateAPI     = "API"
+	CertificateIngress = "Ingress"
+)
+
+type CertificateStatus struct {
+	// Name is the name of the certificate (API or Ingress).
+	Name string `json:"name"`
+
+	// SecretRef is the secret which holds the certificate.
+	SecretRef corev1.SecretReference `json:"secretRef"`
+
+	// NotAfter is the expiry time of the certificate.
+	NotAfter metav1.Time `json:"notAfter"`
+
+	// RenewalTime is the time at which the certificate will be generated again.
+	RenewalTime metav1.Time `json:"renewalTime"`
+}
+
+type RouteAction string
+
+const (
+	// RouteActionDeleted means that the Route was deleted, so that it is re-created by its owner.
+	RouteActionDeleted RouteAction = "Deleted"
+
+	// RouteActionHostRewritten means that the spec.host of the Route was rewritten with the new domain.
+	RouteActionHostRewritten RouteAction = "HostRewritten"
+)
+
+type RouteStatus struct {
+	// Namespace is the namespace of the Route.
+	Namespace string `json:"namespace"`
+
+	// Name is the name of the Route.
+	Name string `json:"name"`
+
+	// RouterName is the name of the IngressController which admitted the Route.
+	RouterName string `json:"routerName"`
+
+	// Action is what was done to the Route (Deleted or HostRewritten).
+	Action RouteAction `json:"action"`
+
+	// OriginalHost is the hostname of the Route before it was first deleted or rewritten.
+	OriginalHost string `json:"originalHost"`
+
+	// Host is the hostname that the Route was rewritten with.
+	Host string `json:"host,omitempty"`
+}
+
+type StepPhase string
+
+const (
+	// StepPhaseRunning means that the step is being applied.
+	StepPhaseRunning StepPhase = "Running"
+
+	// StepPhaseWaiting means that the step has been applied,
+	// and is waiting for the cluster to converge. It is checked again periodically.
+	StepPhaseWaiting StepPhase = "Waiting"
+
+	// StepPhaseCompleted means that the step has completed for the current generation.
+	StepPhaseCompleted StepPhase = "Completed"
+
+	// StepPhaseFailed means that the step returned an error. It is retried with a backoff.
+	StepPhaseFailed StepPhase = "Failed"
+)
+
+type StepStatus struct {
+	// Name is the name of the step.
+	Name string `json:"name"`
+
+	// Phase is the current phase of the step.
+	//+kubebuilder:validation:Enum=Running;Waiting;Completed;Failed
+	Phase StepPhase `json:"phase,omitempty"`
+
+	// WaitingFor describes what the step is waiting for, while it is in the Waiting phase.
+	WaitingFor string `json:"waitingFor,omitempty"`
+
+	// WaitingSince is the time at which the step started waiting. It isn't reset when WaitingFor changes, until the step completes.
+	WaitingSince *metav1.Time `json:"waitingSince,omitempty"`
+
+	// StartTime is the time at which the step started running for the current generation.
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is the time at which the step completed successfully.
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// LastError is the error returned by the most recent failed run of the step.
+	// It is cleared once the step succeeds.
+	LastError string `json:"lastError,omitempty"`
+
+	// ObservedGeneration is the generation of the ClusterRelocation that the step last ran against.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
 type ACMRegistration struct {
 	// URL is the API URL of the ACM cluster.
 	URL string `json:"url"`
@@ -141,6 +432,7 @@ type ACMRegistration struct {
 	ClusterName string `json:"clusterName"`
 
 	// ManagedClusterSet is the ManagedClusterSet that the ManagedCluster will join. Defaults to 'default'.
+	//+kubebuilder:default=default
 	ManagedClusterSet *string `json:"managedClusterSet,omitempty"`
 
 	// acmSecret is a secret reference with credentials for the ACM cluster.
@@ -153,40 +445,3 @@ type ACMRegistration struct {
 	// KlusterletAddonConfig is the klusterlet add-on configuration.
 	KlusterletAddonConfig *agentv1.KlusterletAddonConfigSpec `json:"klusterletAddonConfig,omitempty"`
 }
-
-const (
-	ConditionTypeReady      string = "Ready"
-	ConditionTypeReconciled string = "Reconciled"
-)
-
-const (
-	PullSecretName       string = "pull-secret"
-	BackupPullSecretName string = "backup-pull-secret"
-	ConfigNamespace      string = "openshift-config"
-	IngressNamespace     string = "openshift-ingress"
-)
-
-const (
-	// ValidationSucceededReason represents the fact that the validation of
-	// the resource has succeeded.
-	ValidationSucceededReason string = "ValidationSucceeded"
-
-	// ValidationFailedReason represents the fact that the validation of
-	// the resource has failed.
-	ValidationFailedReason string = "ValidationFailed"
-
-	// ReconciliationSucceededReason represents the fact that the validation of
-	// the resource has succeeded.
-	ReconciliationSucceededReason string = "ReconciliationSucceeded"
-
-	APIReconciliationFailedReason        string = "APIReconciliationFailed"
-	IngressReconciliationFailedReason    string = "IngressReconciliationFailed"
-	PullSecretReconciliationFailedReason string = "PullSecretReconciliationFailed"
-	SSHReconciliationFailedReason        string = "SSHReconciliationFailed"
-	RegistryReconciliationFailedReason   string = "RegistryReconciliationFailed"
-	MirrorReconciliationFailedReason     string = "MirrorReconciliationFailed"
-	CatalogReconciliationFailedReason    string = "CatalogReconciliationFailed"
-	DNSReconciliationFailedReason        string = "DNSReconciliationFailed"
-	ACMReconciliationFailedReason        string = "ACMReconciliationFailed"
-	InProgressReconciliationFailedReason string = "ReconcileInProgress"
-)
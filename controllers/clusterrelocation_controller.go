@@ -18,22 +18,16 @@ package controllers
 
 import (
 	"context"
-	"crypto/tls"
 	"fmt"
-	"net"
+	"strings"
 	"time"
 
-	rhsysenggithubiov1beta1 "github.com/RHsyseng/cluster-relocation-operator/api/v1beta1"
-	reconcileACM "github.com/RHsyseng/cluster-relocation-operator/internal/acm"
-	reconcileAPI "github.com/RHsyseng/cluster-relocation-operator/internal/api"
-	reconcileCatalog "github.com/RHsyseng/cluster-relocation-operator/internal/catalog"
-	reconcileDNS "github.com/RHsyseng/cluster-relocation-operator/internal/dns"
-	reconcileIngress "github.com/RHsyseng/cluster-relocation-operator/internal/ingress"
-	reconcileMirror "github.com/RHsyseng/cluster-relocation-operator/internal/mirror"
-	reconcilePullSecret "github.com/RHsyseng/cluster-relocation-operator/internal/pullSecret"
-	registryCert "github.com/RHsyseng/cluster-relocation-operator/internal/registryCert"
-	reconcileSSH "github.com/RHsyseng/cluster-relocation-operator/internal/ssh"
+	rhsysenggithubiov1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/plan"
+	secrets "github.com/RHsyseng/cluster-relocation-operator/internal/secrets"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/step"
 	"github.com/RHsyseng/cluster-relocation-operator/internal/util"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/validation"
 	agentv1 "github.com/stolostron/klusterlet-addon-controller/pkg/apis/agent/v1"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	operatorapiv1 "open-cluster-management.io/api/operator/v1"
@@ -51,7 +45,6 @@ import (
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -59,6 +52,18 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	// the relocation steps register themselves with the step package
+	_ "github.com/RHsyseng/cluster-relocation-operator/internal/acm"
+	_ "github.com/RHsyseng/cluster-relocation-operator/internal/api"
+	_ "github.com/RHsyseng/cluster-relocation-operator/internal/ca"
+	_ "github.com/RHsyseng/cluster-relocation-operator/internal/catalog"
+	_ "github.com/RHsyseng/cluster-relocation-operator/internal/dns"
+	_ "github.com/RHsyseng/cluster-relocation-operator/internal/ingress"
+	_ "github.com/RHsyseng/cluster-relocation-operator/internal/mirror"
+	_ "github.com/RHsyseng/cluster-relocation-operator/internal/pullSecret"
+	_ "github.com/RHsyseng/cluster-relocation-operator/internal/registryCert"
+	_ "github.com/RHsyseng/cluster-relocation-operator/internal/ssh"
 )
 
 // ClusterRelocationReconciler reconciles a ClusterRelocation object
@@ -89,7 +94,7 @@ const relocationFinalizer = "relocationfinalizer"
 func (r *ClusterRelocationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
 
-	relocation := &rhsysenggithubiov1beta1.ClusterRelocation{}
+	relocation := &rhsysenggithubiov1.ClusterRelocation{}
 
 	if err := r.Get(ctx, req.NamespacedName, relocation); err != nil {
 		if errors.IsNotFound(err) {
@@ -99,6 +104,9 @@ func (r *ClusterRelocationReconciler) Reconcile(ctx context.Context, req ctrl.Re
 		logger.Error(err, "Failed to get ClusterRelocation")
 		return ctrl.Result{}, err
 	}
+	// the defaults are normally set by the mutating webhook
+	// they are set here as well, in case the webhook isn't deployed
+	relocation.Default()
 
 	// Check if the ClusterRelocation instance is marked to be deleted, which is
 	// indicated by the deletion timestamp being set.
@@ -108,10 +116,14 @@ func (r *ClusterRelocationReconciler) Reconcile(ctx context.Context, req ctrl.Re
 			// Run finalization logic for relocationFinalizer. If the
 			// finalization logic fails, don't remove the finalizer so
 			// that we can retry during the next reconciliation.
-			if err := r.finalizeRelocation(ctx, logger, relocation); err != nil {
-				return ctrl.Result{}, err
+			if requeueAfter, err := r.finalizeRelocation(ctx, logger, relocation); err != nil || requeueAfter > 0 {
+				r.updateStatus(ctx, relocation, logger)
+				return ctrl.Result{RequeueAfter: requeueAfter}, err
 			}
 
+			// the certificates were forgotten by the cleanup, stop reporting them
+			secrets.ReportCertificates(relocation)
+
 			// Remove relocationFinalizer. Once all finalizers have been
 			// removed, the object will be deleted.
 			controllerutil.RemoveFinalizer(relocation, relocationFinalizer)
@@ -142,148 +154,292 @@ func (r *ClusterRelocationReconciler) Reconcile(ctx context.Context, req ctrl.Re
 	}
 	logger.Info("validation succeeded")
 
-	clusterVersion := &configv1.ClusterVersion{}
-	if err := r.Client.Get(ctx, types.NamespacedName{Name: "version"}, clusterVersion); err != nil {
-		return ctrl.Result{}, err
-	}
-	clusterVersionString := fmt.Sprintf("v%s", clusterVersion.Status.Desired.Version)
-
 	if !r.WatchingIDMS {
+		clusterVersionString, err := util.GetClusterVersion(ctx, r.Client)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
 		if semver.Compare(clusterVersionString, "v4.12.999") == 1 {
 			// This has to be done dynamically because ImageDigestMirrorSet only exists on OCP 4.13+
-			if err := r.Ctrl.Watch(&source.Kind{Type: &configv1.ImageDigestMirrorSet{}}, &handler.EnqueueRequestForOwner{OwnerType: &rhsysenggithubiov1beta1.ClusterRelocation{}, IsController: true}); err != nil {
+			if err := r.Ctrl.Watch(&source.Kind{Type: &configv1.ImageDigestMirrorSet{}}, &handler.EnqueueRequestForOwner{OwnerType: &rhsysenggithubiov1.ClusterRelocation{}, IsController: true}); err != nil {
 				return ctrl.Result{}, err
 			}
 		}
 		r.WatchingIDMS = true
 	}
 
-	reconcileCondition := apimeta.FindStatusCondition(relocation.Status.Conditions, rhsysenggithubiov1beta1.ConditionTypeReconciled)
+	if relocation.Spec.Mode == rhsysenggithubiov1.ModePlan {
+		return r.planSteps(ctx, relocation, logger)
+	}
+	relocation.Status.Plan = nil
+	apimeta.RemoveStatusCondition(&relocation.Status.Conditions, rhsysenggithubiov1.ConditionTypePlanned)
+
+	reconcileCondition := apimeta.FindStatusCondition(relocation.Status.Conditions, rhsysenggithubiov1.ConditionTypeReconciled)
 	if reconcileCondition == nil || reconcileCondition.ObservedGeneration < relocation.GetGeneration() {
-		r.setFailedStatus(relocation, rhsysenggithubiov1beta1.InProgressReconciliationFailedReason, "reconcile in progress")
+		r.setFailedStatus(relocation, rhsysenggithubiov1.InProgressReconciliationFailedReason, "reconcile in progress")
 		// requeue so that the status is updated right away
 		return ctrl.Result{Requeue: true}, nil
 	}
 
-	if relocation.Spec.AddInternalDNSEntries != nil && *relocation.Spec.AddInternalDNSEntries {
-		// Adds new internal DNS records
-		if err := reconcileDNS.Reconcile(ctx, r.Client, r.Scheme, relocation, logger); err != nil {
-			r.setFailedStatus(relocation, rhsysenggithubiov1beta1.DNSReconciliationFailedReason, err.Error())
-			return ctrl.Result{}, err
-		}
+	if result, err := r.reconcileSteps(ctx, relocation, logger); err != nil || !result.IsZero() {
+		return result, err
 	}
 
-	// Make sure DNS entries work
-	_, err := net.LookupIP(fmt.Sprintf("api.%s", relocation.Spec.Domain))
-	if err != nil {
-		r.setFailedStatus(relocation, rhsysenggithubiov1beta1.DNSReconciliationFailedReason, err.Error())
-		return ctrl.Result{}, err
-	}
-	_, err = net.LookupIP(fmt.Sprintf("test.apps.%s", relocation.Spec.Domain))
-	if err != nil {
-		r.setFailedStatus(relocation, rhsysenggithubiov1beta1.DNSReconciliationFailedReason, err.Error())
-		return ctrl.Result{}, err
+	successCondition := metav1.Condition{
+		Status:             metav1.ConditionTrue,
+		Reason:             rhsysenggithubiov1.ReconciliationSucceededReason,
+		Message:            "reconcile succeeded",
+		Type:               rhsysenggithubiov1.ConditionTypeReconciled,
+		ObservedGeneration: relocation.GetGeneration(),
 	}
+	apimeta.SetStatusCondition(&relocation.Status.Conditions, successCondition)
 
-	// Applies a SSH key for the 'core' user
-	if err := reconcileSSH.Reconcile(ctx, r.Client, r.Scheme, relocation, logger); err != nil {
-		r.setFailedStatus(relocation, rhsysenggithubiov1beta1.SSHReconciliationFailedReason, err.Error())
-		return ctrl.Result{}, err
-	}
+	logger.Info("Reconcile complete")
 
-	// Applies a new registry certificate
-	if err := registryCert.Reconcile(ctx, r.Client, r.Scheme, relocation, logger); err != nil {
-		r.setFailedStatus(relocation, rhsysenggithubiov1beta1.RegistryReconciliationFailedReason, err.Error())
-		return ctrl.Result{}, err
+	if r.isSelfDestructSet(relocation) {
+		logger.Info("self-destruct is set, removing CR")
+		if err := r.Client.Delete(ctx, relocation, client.PropagationPolicy(metav1.DeletePropagationOrphan)); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
 	}
 
-	// Applies new mirror configuration
-	if err := reconcileMirror.Reconcile(ctx, r.Client, r.Scheme, relocation, logger, clusterVersionString); err != nil {
-		r.setFailedStatus(relocation, rhsysenggithubiov1beta1.MirrorReconciliationFailedReason, err.Error())
-		return ctrl.Result{}, err
+	// the generated certificates are renewed by the steps, so the relocation is reconciled again when the next one is due
+	if next := secrets.NextRenewal(relocation); !next.IsZero() {
+		requeueAfter := time.Until(next)
+		if requeueAfter < time.Second {
+			// the certificates of cert-manager are renewed by cert-manager, which may take a while once they are due
+			requeueAfter = step.DefaultRequeueAfter
+		}
+		logger.Info("Requeuing for the next certificate renewal", "RenewalTime", next)
+		return ctrl.Result{RequeueAfter: requeueAfter}, nil
 	}
+	return ctrl.Result{}, nil
+}
 
-	// Apply a new cluster-wide pull secret
-	if err := reconcilePullSecret.Reconcile(ctx, r.Client, r.Scheme, relocation, logger); err != nil {
-		r.setFailedStatus(relocation, rhsysenggithubiov1beta1.PullSecretReconciliationFailedReason, err.Error())
-		return ctrl.Result{}, err
+func (r *ClusterRelocationReconciler) setFailedStatus(relocation *rhsysenggithubiov1.ClusterRelocation, reason string, message string) {
+	failedCondition := metav1.Condition{
+		Status:             metav1.ConditionFalse,
+		Reason:             reason,
+		Message:            message,
+		Type:               rhsysenggithubiov1.ConditionTypeReconciled,
+		ObservedGeneration: relocation.GetGeneration(),
 	}
+	apimeta.SetStatusCondition(&relocation.Status.Conditions, failedCondition)
+}
 
-	// Applies new catalog sources
-	if err := reconcileCatalog.Reconcile(ctx, r.Client, r.Scheme, relocation, logger); err != nil {
-		r.setFailedStatus(relocation, rhsysenggithubiov1beta1.CatalogReconciliationFailedReason, err.Error())
-		return ctrl.Result{}, err
+// reconcileSteps runs each registered step in order.
+// It stops at the first step which fails, or which is waiting for the cluster to converge.
+// In the latter case, the returned Result requeues the reconcile.
+func (r *ClusterRelocationReconciler) reconcileSteps(ctx context.Context, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) (ctrl.Result, error) {
+	// While the relocation is in progress, skip the steps that have already completed for this generation.
+	// This way, the relocation resumes where it left off after a requeue or a restart of the operator.
+	// Once the relocation has completed, every step is run again in order to correct any drift.
+	reconcileCondition := apimeta.FindStatusCondition(relocation.Status.Conditions, rhsysenggithubiov1.ConditionTypeReconciled)
+	inProgress := reconcileCondition == nil || reconcileCondition.Status != metav1.ConditionTrue || reconcileCondition.ObservedGeneration != relocation.GetGeneration()
+
+	for _, s := range step.Steps() {
+		if inProgress {
+			stepStatus := getStepStatus(relocation, s.Name())
+			if stepStatus.Phase == rhsysenggithubiov1.StepPhaseCompleted && stepStatus.ObservedGeneration == relocation.GetGeneration() {
+				continue
+			}
+		}
+		requeueAfter, err := r.runStep(ctx, relocation, s, logger)
+		if err != nil || requeueAfter > 0 {
+			return ctrl.Result{RequeueAfter: requeueAfter}, err
+		}
 	}
+	return ctrl.Result{}, nil
+}
 
-	// Applies a new certificate and domain alias to the Ingress
-	if err := reconcileIngress.Reconcile(ctx, r.Client, r.Scheme, relocation, logger); err != nil {
-		r.setFailedStatus(relocation, rhsysenggithubiov1beta1.IngressReconciliationFailedReason, err.Error())
-		return ctrl.Result{}, err
+// planSteps runs each registered step against a plan.Client, and reports the changes that it would make in Status.Plan.
+// Unlike reconcileSteps, it continues past the steps which fail or wait, so that the plan is as complete as possible.
+// Nothing is applied to the cluster.
+func (r *ClusterRelocationReconciler) planSteps(ctx context.Context, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) (ctrl.Result, error) {
+	planClient := plan.NewClient(r.Client)
+	// the steps record their status (e.g. the certificates or the Routes) on a copy of the CR, which is discarded.
+	// Only Status.Plan and the Planned condition are written to the CR
+	planned := relocation.DeepCopy()
+	planStatus := &rhsysenggithubiov1.PlanStatus{ObservedGeneration: relocation.GetGeneration()}
+	incomplete := []string{}
+	for _, s := range step.Steps() {
+		stepLogger := logger.WithValues("step", s.Name(), "mode", rhsysenggithubiov1.ModePlan)
+		var err error
+		if s.Enabled(planned) {
+			err = s.Reconcile(ctx, planClient, r.Scheme, planned, stepLogger)
+		} else {
+			err = s.Cleanup(ctx, planClient, r.Scheme, planned, stepLogger)
+		}
+		plannedStep := rhsysenggithubiov1.PlannedStep{Name: s.Name(), Changes: planClient.TakeChanges()}
+		if err != nil {
+			if waitingErr, ok := step.AsWaitingError(err); ok {
+				plannedStep.WaitingFor = waitingErr.For
+			} else {
+				plannedStep.Error = err.Error()
+			}
+			incomplete = append(incomplete, s.Name())
+		}
+		planStatus.Steps = append(planStatus.Steps, plannedStep)
 	}
+	relocation.Status.Plan = planStatus
 
-	// Applies a new certificate and domain alias to the API server
-	if err := reconcileAPI.Reconcile(ctx, r.Client, r.Scheme, relocation, logger); err != nil {
-		r.setFailedStatus(relocation, rhsysenggithubiov1beta1.APIReconciliationFailedReason, err.Error())
-		return ctrl.Result{}, err
+	plannedCondition := metav1.Condition{
+		Status:             metav1.ConditionTrue,
+		Reason:             rhsysenggithubiov1.PlanCompleteReason,
+		Message:            "every step was planned",
+		Type:               rhsysenggithubiov1.ConditionTypePlanned,
+		ObservedGeneration: relocation.GetGeneration(),
+	}
+	if len(incomplete) > 0 {
+		plannedCondition.Status = metav1.ConditionFalse
+		plannedCondition.Reason = rhsysenggithubiov1.PlanIncompleteReason
+		plannedCondition.Message = fmt.Sprintf("could not fully plan the steps: %s", strings.Join(incomplete, ", "))
 	}
+	apimeta.SetStatusCondition(&relocation.Status.Conditions, plannedCondition)
+	r.setFailedStatus(relocation, rhsysenggithubiov1.PlanModeReason, "the relocation is in Plan mode, no changes were applied")
 
-	if err := r.verifyDomain(ctx, relocation.Spec.Domain, logger); err != nil {
-		r.setFailedStatus(relocation, rhsysenggithubiov1beta1.InProgressReconciliationFailedReason, err.Error())
-		return ctrl.Result{}, err
+	logger.Info("Plan complete")
+	return ctrl.Result{}, nil
+}
+
+// runStep runs a single step of the relocation, recording its progress in the step's condition,
+// in Status.Steps and, if it doesn't complete, in the Reconciled condition.
+// Steps which are not enabled are cleaned up, in case they were enabled previously.
+// If the step is waiting for the cluster to converge, the delay before it should be run again is returned.
+func (r *ClusterRelocationReconciler) runStep(ctx context.Context, relocation *rhsysenggithubiov1.ClusterRelocation, s step.Step, logger logr.Logger) (time.Duration, error) {
+	stepStatus := getStepStatus(relocation, s.Name())
+	if stepStatus.ObservedGeneration != relocation.GetGeneration() || stepStatus.StartTime == nil {
+		// only reset the timestamps when the step runs against a new generation
+		// otherwise every reconcile would modify the status, and trigger another reconcile
+		now := metav1.Now()
+		stepStatus.StartTime = &now
+		stepStatus.CompletionTime = nil
+		stepStatus.WaitingFor = ""
+		stepStatus.WaitingSince = nil
+		stepStatus.ObservedGeneration = relocation.GetGeneration()
+	}
+	if stepStatus.CompletionTime == nil && stepStatus.WaitingSince == nil {
+		stepStatus.Phase = rhsysenggithubiov1.StepPhaseRunning
+		apimeta.SetStatusCondition(&relocation.Status.Conditions, metav1.Condition{
+			Status:             metav1.ConditionUnknown,
+			Reason:             rhsysenggithubiov1.StepInProgressReason,
+			Message:            fmt.Sprintf("%s step in progress", s.Name()),
+			Type:               s.ConditionType(),
+			ObservedGeneration: relocation.GetGeneration(),
+		})
 	}
 
-	if err := reconcileIngress.ResetRoutes(ctx, r.Client, fmt.Sprintf("apps.%s", relocation.Spec.Domain), logger); err != nil {
-		r.setFailedStatus(relocation, rhsysenggithubiov1beta1.InProgressReconciliationFailedReason, err.Error())
-		return ctrl.Result{}, err
+	stepLogger := logger.WithValues("step", s.Name())
+	var err error
+	enabled := s.Enabled(relocation)
+	if enabled {
+		err = s.Reconcile(ctx, r.Client, r.Scheme, relocation, stepLogger)
+	} else {
+		err = s.Cleanup(ctx, r.Client, r.Scheme, relocation, stepLogger)
 	}
+	if err != nil {
+		if waitingErr, ok := step.AsWaitingError(err); ok {
+			var requeueAfter time.Duration
+			requeueAfter, err = trackWait(stepStatus, waitingErr)
+			if err == nil {
+				stepStatus.CompletionTime = nil
+				apimeta.SetStatusCondition(&relocation.Status.Conditions, metav1.Condition{
+					Status:             metav1.ConditionUnknown,
+					Reason:             rhsysenggithubiov1.StepWaitingReason,
+					Message:            waitingErr.Error(),
+					Type:               s.ConditionType(),
+					ObservedGeneration: relocation.GetGeneration(),
+				})
+				r.setFailedStatus(relocation, rhsysenggithubiov1.InProgressReconciliationFailedReason, fmt.Sprintf("%s step is %s", s.Name(), waitingErr.Error()))
+				return requeueAfter, nil
+			}
+		}
 
-	// Registers to ACM
-	if err := reconcileACM.Reconcile(ctx, r.Client, r.Scheme, relocation, logger); err != nil {
-		r.setFailedStatus(relocation, rhsysenggithubiov1beta1.ACMReconciliationFailedReason, err.Error())
-		return ctrl.Result{}, err
+		reason := s.FailureReason()
+		if reasonErr, ok := step.AsReasonError(err); ok {
+			reason = reasonErr.Reason()
+		}
+		if _, ok := err.(*step.TimeoutError); ok {
+			reason = rhsysenggithubiov1.TimedOutReason
+		}
+		stepStatus.Phase = rhsysenggithubiov1.StepPhaseFailed
+		stepStatus.LastError = err.Error()
+		stepStatus.CompletionTime = nil
+		apimeta.SetStatusCondition(&relocation.Status.Conditions, metav1.Condition{
+			Status:             metav1.ConditionFalse,
+			Reason:             reason,
+			Message:            err.Error(),
+			Type:               s.ConditionType(),
+			ObservedGeneration: relocation.GetGeneration(),
+		})
+		r.setFailedStatus(relocation, reason, err.Error())
+		return 0, err
 	}
 
+	stepStatus.Phase = rhsysenggithubiov1.StepPhaseCompleted
+	stepStatus.LastError = ""
+	stepStatus.WaitingFor = ""
+	stepStatus.WaitingSince = nil
+	if stepStatus.CompletionTime == nil {
+		now := metav1.Now()
+		stepStatus.CompletionTime = &now
+	}
 	successCondition := metav1.Condition{
 		Status:             metav1.ConditionTrue,
-		Reason:             rhsysenggithubiov1beta1.ReconciliationSucceededReason,
-		Message:            "reconcile succeeded",
-		Type:               rhsysenggithubiov1beta1.ConditionTypeReconciled,
+		Reason:             rhsysenggithubiov1.ReconciliationSucceededReason,
+		Message:            fmt.Sprintf("%s step succeeded", s.Name()),
+		Type:               s.ConditionType(),
 		ObservedGeneration: relocation.GetGeneration(),
 	}
+	if !enabled {
+		successCondition.Reason = rhsysenggithubiov1.StepNotConfiguredReason
+		successCondition.Message = fmt.Sprintf("%s step is not configured", s.Name())
+	}
 	apimeta.SetStatusCondition(&relocation.Status.Conditions, successCondition)
+	return 0, nil
+}
 
-	logger.Info("Reconcile complete")
-
-	if r.isSelfDestructSet(relocation) {
-		logger.Info("self-destruct is set, removing CR")
-		if err := r.Client.Delete(ctx, relocation, client.PropagationPolicy(metav1.DeletePropagationOrphan)); err != nil {
-			return ctrl.Result{}, err
-		}
-	}
-	return ctrl.Result{}, nil
+// trackWait records the wait in the step's status, so that it survives requeues and restarts of the operator.
+// The timeout applies to the step rather than to what it waits for, so that a step whose endpoints flap between several conditions still times out.
+// It returns the delay before the step should be run again, or an error if the step has waited longer than its timeout
+func trackWait(stepStatus *rhsysenggithubiov1.StepStatus, waitingErr *step.WaitingError) (time.Duration, error) {
+	if stepStatus.WaitingSince == nil {
+		now := metav1.Now()
+		stepStatus.WaitingSince = &now
+	}
+	stepStatus.WaitingFor = waitingErr.For
+	if waitingErr.Timeout > 0 && time.Since(stepStatus.WaitingSince.Time) > waitingErr.Timeout {
+		// the wait is kept in the status, so that the step keeps reporting that it timed out
+		// it still completes if the cluster eventually converges
+		return 0, &step.TimeoutError{Waiting: waitingErr}
+	}
+	stepStatus.Phase = rhsysenggithubiov1.StepPhaseWaiting
+	return waitingErr.RequeueAfter, nil
 }
 
-func (r *ClusterRelocationReconciler) setFailedStatus(relocation *rhsysenggithubiov1beta1.ClusterRelocation, reason string, message string) {
-	failedCondition := metav1.Condition{
-		Status:             metav1.ConditionFalse,
-		Reason:             reason,
-		Message:            message,
-		Type:               rhsysenggithubiov1beta1.ConditionTypeReconciled,
-		ObservedGeneration: relocation.GetGeneration(),
+// returns the status entry for the named step, adding one if it doesn't exist yet
+func getStepStatus(relocation *rhsysenggithubiov1.ClusterRelocation, name string) *rhsysenggithubiov1.StepStatus {
+	for i := range relocation.Status.Steps {
+		if relocation.Status.Steps[i].Name == name {
+			return &relocation.Status.Steps[i]
+		}
 	}
-	apimeta.SetStatusCondition(&relocation.Status.Conditions, failedCondition)
+	relocation.Status.Steps = append(relocation.Status.Steps, rhsysenggithubiov1.StepStatus{Name: name})
+	return &relocation.Status.Steps[len(relocation.Status.Steps)-1]
 }
 
 // We ensure that the CR is named "cluster"
 // This makes it so that only 1 CR is reconciled per cluster (since the CR is cluster-scoped)
-func validateCR(relocation *rhsysenggithubiov1beta1.ClusterRelocation) error {
-	if relocation.Name != "cluster" {
-		err := fmt.Errorf("invalid name: %s. CR name must be: cluster", relocation.Name)
+// The rest of the spec is checked as well, in case the validating webhook is not deployed
+func validateCR(relocation *rhsysenggithubiov1.ClusterRelocation) error {
+	if allErrs := validation.Validate(relocation); len(allErrs) > 0 {
+		err := allErrs.ToAggregate()
 		readyCondition := metav1.Condition{
 			Status:             metav1.ConditionFalse,
-			Reason:             rhsysenggithubiov1beta1.ValidationFailedReason,
+			Reason:             rhsysenggithubiov1.ValidationFailedReason,
 			Message:            err.Error(),
-			Type:               rhsysenggithubiov1beta1.ConditionTypeReady,
+			Type:               rhsysenggithubiov1.ConditionTypeReady,
 			ObservedGeneration: relocation.GetGeneration(),
 		}
 		apimeta.SetStatusCondition(&relocation.Status.Conditions, readyCondition)
@@ -292,116 +448,65 @@ func validateCR(relocation *rhsysenggithubiov1beta1.ClusterRelocation) error {
 
 	readyCondition := metav1.Condition{
 		Status:             metav1.ConditionTrue,
-		Reason:             rhsysenggithubiov1beta1.ValidationSucceededReason,
-		Type:               rhsysenggithubiov1beta1.ConditionTypeReady,
+		Reason:             rhsysenggithubiov1.ValidationSucceededReason,
+		Type:               rhsysenggithubiov1.ConditionTypeReady,
 		ObservedGeneration: relocation.GetGeneration(),
 	}
 	apimeta.SetStatusCondition(&relocation.Status.Conditions, readyCondition)
 	return nil
 }
 
-func (r *ClusterRelocationReconciler) updateStatus(ctx context.Context, relocation *rhsysenggithubiov1beta1.ClusterRelocation, logger logr.Logger) {
+func (r *ClusterRelocationReconciler) updateStatus(ctx context.Context, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) {
+	secrets.ReportCertificates(relocation)
 	if err := r.Status().Update(ctx, relocation); err != nil {
 		logger.Error(err, "Failed to update Status")
 	}
 }
 
-func (r *ClusterRelocationReconciler) finalizeRelocation(ctx context.Context, logger logr.Logger, relocation *rhsysenggithubiov1beta1.ClusterRelocation) error {
+// finalizeRelocation reverts the changes made by the relocation.
+// If a step is waiting for the cluster to converge, the delay before the finalizer should be run again is returned.
+func (r *ClusterRelocationReconciler) finalizeRelocation(ctx context.Context, logger logr.Logger, relocation *rhsysenggithubiov1.ClusterRelocation) (time.Duration, error) {
 	logger.Info("Starting finalizer")
 
 	if r.isSelfDestructSet(relocation) {
 		subscriptions := &operatorhubv1alpha1.SubscriptionList{}
 		if err := r.Client.List(ctx, subscriptions, client.InNamespace("openshift-operators")); err != nil {
-			return err
+			return 0, err
 		}
 		for _, v := range subscriptions.Items {
 			if v.Spec.Package == "cluster-relocation-operator" {
 				csv := &operatorhubv1alpha1.ClusterServiceVersion{ObjectMeta: metav1.ObjectMeta{Name: v.Status.CurrentCSV, Namespace: v.Namespace}}
 				if err := r.Client.Delete(ctx, &v); err != nil {
-					return err
+					return 0, err
 				}
 				if err := r.Client.Delete(ctx, csv); err != nil {
-					return err
+					return 0, err
 				}
 				logger.Info("operator deleted")
 			}
 		}
+	} else if relocation.Spec.Mode == rhsysenggithubiov1.ModePlan && len(relocation.Status.Steps) == 0 {
+		logger.Info("the relocation was only planned, nothing to clean up")
 	} else {
-		if err := reconcilePullSecret.Cleanup(ctx, r.Client, r.Scheme, relocation, logger); err != nil {
-			return err
-		}
-
-		if err := registryCert.Cleanup(ctx, r.Client, logger); err != nil {
-			return err
-		}
-
-		if err := reconcileIngress.Cleanup(ctx, r.Client, logger); err != nil {
-			return err
-		}
-
-		if err := reconcileAPI.Cleanup(ctx, r.Client, logger); err != nil {
-			return err
-		}
-
-		clusterDNS := &configv1.DNS{}
-		if err := r.Client.Get(ctx, types.NamespacedName{Name: "cluster"}, clusterDNS); err != nil {
-			return err
-		}
-		if err := r.verifyDomain(ctx, clusterDNS.Spec.BaseDomain, logger); err != nil {
-			return err
-		}
-
-		if err := reconcileIngress.ResetRoutes(ctx, r.Client, fmt.Sprintf("apps.%s", clusterDNS.Spec.BaseDomain), logger); err != nil {
-			return err
-		}
-	}
-
-	logger.Info("Successfully finalized ClusterRelocation")
-	return nil
-}
-
-func (r *ClusterRelocationReconciler) verifyDomain(ctx context.Context, domainName string, logger logr.Logger) error {
-	urls := []map[string]string{
-		{
-			"type":       "ingress",
-			"url":        fmt.Sprintf("test.apps.%s:443", domainName),
-			"commonName": fmt.Sprintf("*.apps.%s", domainName),
-		},
-		{
-			"type":       "kube-apiserver",
-			"url":        fmt.Sprintf("api.%s:6443", domainName),
-			"commonName": fmt.Sprintf("api.%s", domainName),
-		},
-	}
-
-	for _, v := range urls {
-		updated := false
-		for {
-			conn, err := tls.Dial("tcp", v["url"], &tls.Config{InsecureSkipVerify: true})
-			if err != nil {
-				return err
-			}
-			certs := conn.ConnectionState().PeerCertificates
-			conn.Close()
-			for _, cert := range certs {
-				if cert.Subject.CommonName == v["commonName"] {
-					updated = true
+		// steps are cleaned up in the reverse order in which they were applied
+		for _, s := range step.CleanupSteps() {
+			if err := s.Cleanup(ctx, r.Client, r.Scheme, relocation, logger.WithValues("step", s.Name())); err != nil {
+				stepStatus := getStepStatus(relocation, s.Name())
+				if waitingErr, ok := step.AsWaitingError(err); ok {
+					var requeueAfter time.Duration
+					if requeueAfter, err = trackWait(stepStatus, waitingErr); err == nil {
+						return requeueAfter, nil
+					}
 				}
-			}
-			if updated {
-				// ensure that ClusterOperator has settled
-				if err := util.WaitForCO(ctx, r.Client, logger, v["type"]); err != nil {
-					return err
-				}
-				break
-			} else {
-				logger.Info(fmt.Sprintf("Waiting for %s to update", v["type"]))
-				time.Sleep(time.Second * 10)
+				stepStatus.Phase = rhsysenggithubiov1.StepPhaseFailed
+				stepStatus.LastError = err.Error()
+				return 0, fmt.Errorf("%s cleanup failed: %w", s.Name(), err)
 			}
 		}
 	}
 
-	return nil
+	logger.Info("Successfully finalized ClusterRelocation")
+	return 0, nil
 }
 
 func (r *ClusterRelocationReconciler) installSchemes() error {
@@ -443,7 +548,7 @@ func (r *ClusterRelocationReconciler) installSchemes() error {
 	return nil
 }
 
-func (r *ClusterRelocationReconciler) isSelfDestructSet(relocation *rhsysenggithubiov1beta1.ClusterRelocation) bool {
+func (r *ClusterRelocationReconciler) isSelfDestructSet(relocation *rhsysenggithubiov1.ClusterRelocation) bool {
 	selfDestruct := false
 	val, ok := relocation.Annotations["self-destruct"]
 	if ok {
@@ -461,11 +566,11 @@ func (r *ClusterRelocationReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	}
 
 	controller, err := ctrl.NewControllerManagedBy(mgr).
-		For(&rhsysenggithubiov1beta1.ClusterRelocation{}).
+		For(&rhsysenggithubiov1.ClusterRelocation{}).
 		// for user provided certificates, we set a non-controller ownership in order to watch for changes
 		// Owns() only watches for 'IsController: true' ownership, so we need to watch Secrets this way
 		// 'IsController: false' watches for all types of ownership (including controller ownership)
-		Watches(&source.Kind{Type: &corev1.Secret{}}, &handler.EnqueueRequestForOwner{OwnerType: &rhsysenggithubiov1beta1.ClusterRelocation{}, IsController: false}).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, &handler.EnqueueRequestForOwner{OwnerType: &rhsysenggithubiov1.ClusterRelocation{}, IsController: false}).
 		Owns(&corev1.ConfigMap{}).
 		Owns(&operatorhubv1alpha1.CatalogSource{}).
 		Owns(&machineconfigurationv1.MachineConfig{}).
@@ -5,10 +5,13 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"time"
 
-	rhsysenggithubiov1beta1 "github.com/RHsyseng/cluster-relocation-operator/api/v1beta1"
+	rhsysenggithubiov1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/api"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/backup"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/plan"
 	secrets "github.com/RHsyseng/cluster-relocation-operator/internal/secrets"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/step"
 	"github.com/go-logr/logr"
 	configv1 "github.com/openshift/api/config/v1"
 	agentv1 "github.com/stolostron/klusterlet-addon-controller/pkg/apis/agent/v1"
@@ -68,7 +71,7 @@ import (
 //+kubebuilder:rbac:groups=operator.open-cluster-management.io,resources=klusterlets,verbs=get;list;watch;create;update;patch;delete
 
 // returns nil if the Klusterlet is Available, error otherwise
-func checkKlusterlet(ctx context.Context, c client.Client, relocation *rhsysenggithubiov1beta1.ClusterRelocation, logger logr.Logger) error {
+func checkKlusterlet(ctx context.Context, c client.Client, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
 	klusterlet := &operatorapiv1.Klusterlet{}
 	err := c.Get(ctx, types.NamespacedName{Name: "klusterlet"}, klusterlet)
 	if err == nil {
@@ -91,7 +94,7 @@ func checkKlusterlet(ctx context.Context, c client.Client, relocation *rhsysengg
 	return err
 }
 
-func Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1beta1.ClusterRelocation, logger logr.Logger) error {
+func Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
 	if relocation.Spec.ACMRegistration == nil {
 		return nil
 	}
@@ -126,32 +129,86 @@ func Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, rel
 	if err != nil {
 		return err
 	}
+	// when the relocation is being planned, the writes to the ACM cluster are planned as well
+	acmClient = plan.ForRemote(c, acmClient)
 
-	managedClusterSet := "default"
+	managedClusterSet := rhsysenggithubiov1.DefaultManagedClusterSet
 	if relocation.Spec.ACMRegistration.ManagedClusterSet != nil {
 		managedClusterSet = *relocation.Spec.ACMRegistration.ManagedClusterSet
 	}
 
-	apiServer := &configv1.APIServer{}
-	if err := c.Get(ctx, types.NamespacedName{Name: "cluster"}, apiServer); err != nil {
+	// the named certificate applied by the API step is recorded in the backup, the other entries belong to the cluster owner
+	apiSecretName := ""
+	applied := configv1.APIServerNamedServingCert{}
+	appliedFound, err := backup.Restore(ctx, c, backup.AppliedAPIServerNamedCertificateKey, &applied)
+	if err != nil {
 		return err
 	}
-	var caBundle []byte
-	for _, v := range apiServer.Spec.ServingCerts.NamedCertificates {
-		if v.Names[0] == fmt.Sprintf("api.%s", relocation.Spec.Domain) {
-			apiSecret := &corev1.Secret{}
-			if err := c.Get(ctx, types.NamespacedName{Name: v.ServingCertificate.Name, Namespace: rhsysenggithubiov1beta1.ConfigNamespace}, apiSecret); err != nil {
-				return err
+	if appliedFound {
+		apiSecretName = applied.ServingCertificate.Name
+	} else {
+		apiServer := &configv1.APIServer{}
+		if err := c.Get(ctx, types.NamespacedName{Name: "cluster"}, apiServer); err != nil {
+			return err
+		}
+		for _, v := range apiServer.Spec.ServingCerts.NamedCertificates {
+			for _, name := range v.Names {
+				if name == fmt.Sprintf("api.%s", relocation.Spec.Domain) {
+					apiSecretName = v.ServingCertificate.Name
+				}
 			}
-			caBundle = apiSecret.Data[corev1.TLSCertKey]
 		}
 	}
+	var caBundle []byte
+	if apiSecretName != "" {
+		apiSecret := &corev1.Secret{}
+		if err := c.Get(ctx, types.NamespacedName{Name: apiSecretName, Namespace: rhsysenggithubiov1.ConfigNamespace}, apiSecret); err != nil {
+			return err
+		}
+		caBundle = apiSecret.Data[corev1.TLSCertKey]
+		if caCert, ok := apiSecret.Data["ca.crt"]; ok && relocation.Spec.APICertRef == nil && relocation.Spec.CertManager != nil {
+			// the certificate is renewed by cert-manager, so the hub trusts the CA of the issuer, when cert-manager provides it
+			caBundle = caCert
+		}
+	}
+	if relocation.Spec.APICertRef == nil && relocation.Spec.CertManager == nil && relocation.Spec.CertificateGeneration.GetCAType() != rhsysenggithubiov1.CATypeLoadBalancerServingSigner {
+		// the generated certificate is renewed regularly, so the hub trusts the relocation CA instead
+		signer, err := secrets.GetSigner(ctx, c, relocation)
+		if err != nil {
+			return err
+		}
+		caBundle = signer.CertificatePEM
+	}
 
 	clusterDNS := &configv1.DNS{}
 	if err := c.Get(ctx, types.NamespacedName{Name: "cluster"}, clusterDNS); err != nil {
 		return err
 	}
 
+	// every hostname that serves the relocated certificate is registered, with api.<domain> as the first item in the list
+	hostnames, err := api.Hostnames(ctx, c, relocation)
+	if err != nil {
+		return err
+	}
+	clientConfigs := []clusterv1.ClientConfig{}
+	for _, v := range hostnames {
+		clientConfigs = append(clientConfigs, clusterv1.ClientConfig{
+			URL:      fmt.Sprintf("https://%s:6443", v),
+			CABundle: caBundle,
+		})
+	}
+	originalHostname := fmt.Sprintf("api.%s", clusterDNS.Spec.BaseDomain)
+	servesOriginalHostname := false
+	for _, v := range hostnames {
+		servesOriginalHostname = servesOriginalHostname || v == originalHostname
+	}
+	if !servesOriginalHostname {
+		// the original domain is still served with the original certificate
+		clientConfigs = append(clientConfigs, clusterv1.ClientConfig{
+			URL: fmt.Sprintf("https://%s:6443", originalHostname),
+		})
+	}
+
 	managedCluster := &clusterv1.ManagedCluster{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: relocation.Spec.ACMRegistration.ClusterName,
@@ -162,17 +219,8 @@ func Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, rel
 			},
 		},
 		Spec: clusterv1.ManagedClusterSpec{
-			HubAcceptsClient: true,
-			ManagedClusterClientConfigs: []clusterv1.ClientConfig{
-				{
-					URL:      fmt.Sprintf("https://api.%s:6443", relocation.Spec.Domain),
-					CABundle: caBundle,
-				},
-				{
-					// putting this here ensures that the new API URL is the first item in the list
-					URL: fmt.Sprintf("https://api.%s:6443", clusterDNS.Spec.BaseDomain),
-				},
-			},
+			HubAcceptsClient:            true,
+			ManagedClusterClientConfigs: clientConfigs,
 		},
 	}
 	if err := acmClient.Create(ctx, managedCluster); err != nil {
@@ -183,24 +231,15 @@ func Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, rel
 		}
 	}
 
-	startTime := time.Now()
 	logger.Info("getting ACM import secret")
 	importSecret := &corev1.Secret{}
-	for {
-		if err := acmClient.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("%s-import", relocation.Spec.ACMRegistration.ClusterName), Namespace: relocation.Spec.ACMRegistration.ClusterName}, importSecret); err != nil {
-			// after the ManagedCluster is created, it can take some time for this secret and the RBAC roles to be created
-			if errors.IsNotFound(err) || errors.IsForbidden(err) {
-				time.Sleep(time.Second * 10)
-
-				// we set a 5 minute timeout in case the ACM import secret can never be pulled
-				if time.Since(startTime) > time.Minute*5 {
-					return fmt.Errorf("could not get ACM import secret")
-				}
-				continue
-			}
-			return err
+	if err := acmClient.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("%s-import", relocation.Spec.ACMRegistration.ClusterName), Namespace: relocation.Spec.ACMRegistration.ClusterName}, importSecret); err != nil {
+		// after the ManagedCluster is created, it can take some time for this secret and the RBAC roles to be created
+		if errors.IsNotFound(err) || errors.IsForbidden(err) {
+			// we set a timeout in case the ACM import secret can never be pulled
+			return step.Wait("ACM import secret").WithTimeout(relocation.Spec.Timeouts.GetACMImport())
 		}
-		break
+		return err
 	}
 
 	if relocation.Spec.ACMRegistration.KlusterletAddonConfig != nil {
@@ -264,18 +303,11 @@ func Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, rel
 		}
 	}
 
-	logger.Info("waiting for Klusterlet to become Available")
 	// wait for the Klusterlet to become Available
-	startTime = time.Now()
-	for {
-		if checkKlusterlet(ctx, c, relocation, logger) == nil {
-			return nil
-		}
-		time.Sleep(time.Second * 10)
-
-		// we set a 5 minute timeout in case the Klusterlet never gets to Available
-		if time.Since(startTime) > time.Minute*5 {
-			return fmt.Errorf("klusterlet error")
-		}
+	if checkKlusterlet(ctx, c, relocation, logger) != nil {
+		logger.Info("waiting for Klusterlet to become Available")
+		// we set a timeout in case the Klusterlet never gets to Available
+		return step.Wait("Klusterlet to become Available").WithTimeout(relocation.Spec.Timeouts.GetKlusterletAvailable())
 	}
+	return nil
 }
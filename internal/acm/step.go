@@ -0,0 +1,36 @@
+package acm
+
+import (
+	"context"
+
+	rhsysenggithubiov1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/step"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func init() {
+	step.Register(step.OrderACM, acmStep{})
+}
+
+type acmStep struct{}
+
+func (acmStep) Name() string { return rhsysenggithubiov1.StepACM }
+
+func (acmStep) ConditionType() string { return rhsysenggithubiov1.ConditionTypeACMRegistered }
+
+func (acmStep) FailureReason() string { return rhsysenggithubiov1.ACMReconciliationFailedReason }
+
+func (acmStep) Enabled(relocation *rhsysenggithubiov1.ClusterRelocation) bool {
+	return relocation.Spec.ACMRegistration != nil
+}
+
+func (acmStep) Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
+	return Reconcile(ctx, c, scheme, relocation, logger)
+}
+
+// The cluster stays registered to ACM when the CR is deleted
+func (acmStep) Cleanup(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
+	return nil
+}
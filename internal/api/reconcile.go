@@ -4,12 +4,16 @@ import (
 	"context"
 	"fmt"
 
-	rhsysenggithubiov1beta1 "github.com/RHsyseng/cluster-relocation-operator/api/v1beta1"
+	rhsysenggithubiov1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/backup"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/certmanager"
 	secrets "github.com/RHsyseng/cluster-relocation-operator/internal/secrets"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/util"
 	"github.com/go-logr/logr"
 
 	configv1 "github.com/openshift/api/config/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -19,41 +23,64 @@ import (
 //+kubebuilder:rbac:groups="",resources=secrets,verbs=create;update;get;list;watch
 //+kubebuilder:rbac:groups=config.openshift.io,resources=apiservers,verbs=patch;get;list;watch
 
-func Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1beta1.ClusterRelocation, logger logr.Logger) error {
+func Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
+	hostnames, err := Hostnames(ctx, c, relocation)
+	if err != nil {
+		return err
+	}
+	// the certificate covers every hostname of the API server, along with the additional subject alternative names of the CertificateGeneration
+	additionalNames := append(append([]string{}, hostnames[1:]...), relocation.Spec.CertificateGeneration.GetAPIAdditionalNames()...)
+
 	var origSecretName string
 	var origSecretNamespace string
-	if relocation.Spec.APICertRef == nil {
+	certRef := relocation.Spec.APICertRef
+	if certRef == nil && relocation.Spec.CertManager != nil {
+		// the certificate is requested from cert-manager, then used in the same way as a user provided certificate
+		certRef, err = certmanager.Reconcile(ctx, c, scheme, relocation, logger, rhsysenggithubiov1.CertificateAPI, hostnames[0], additionalNames)
+		if err != nil {
+			return err
+		}
+	}
+	if certRef == nil {
 		// If they haven't specified an APICertRef, we generate a certificate for them
 		origSecretName = "generated-api-secret"
-		origSecretNamespace = rhsysenggithubiov1beta1.ConfigNamespace
+		origSecretNamespace = rhsysenggithubiov1.ConfigNamespace
 		secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: origSecretName, Namespace: origSecretNamespace}}
 
+		generation := relocation.Spec.CertificateGeneration
+		signer, err := secrets.GetSigner(ctx, c, relocation)
+		if err != nil {
+			return err
+		}
+		request := secrets.NewCertificateRequest(relocation.Spec.Domain, "api", additionalNames, generation, signer)
+		hash, err := request.Hash()
+		if err != nil {
+			return err
+		}
 		op, err := controllerutil.CreateOrUpdate(ctx, c, secret, func() error {
+			// The hash of the request is stored in an annotation, so that we don't generate a new certificate each time Reconcile runs,
+			// but only when there is no certificate yet, when the domain or the generation settings have changed, or when it is about to expire
 			_, ok := secret.Data[corev1.TLSCertKey]
-			// Check if the secret already has a key set
-			// If there is no key set, generate one
-			// This is done so that we don't generate a new certificate each time Reconcile runs
-			if !ok {
-				logger.Info("generating new TLS cert for API")
+			generate := !ok || secret.Annotations[secrets.CertificateHashAnnotation] != hash
+			if !generate {
+				renew, err := secrets.NeedsRenewal(secret, generation.GetRenewBefore())
+				if err != nil {
+					logger.Info("could not read the TLS cert for API, generating a new one", "error", err.Error())
+				} else if renew {
+					logger.Info("TLS cert for API is about to expire, generating a new one")
+				}
+				generate = err != nil || renew
+			}
+			if generate {
+				logger.Info("generating new TLS cert for API", "KeyAlgorithm", request.KeyAlgorithm, "KeySize", request.KeySize, "DNSNames", request.DNSNames, "IPAddresses", request.IPAddresses)
 				var err error
-				secret.Data, err = secrets.GenerateTLSKeyPair(ctx, c, relocation.Spec.Domain, "api")
+				secret.Data, err = secrets.GenerateTLSKeyPair(signer, request)
 				if err != nil {
 					return err
 				}
+				metav1.SetMetaDataAnnotation(&secret.ObjectMeta, secrets.CertificateHashAnnotation, hash)
 			} else {
 				logger.Info("TLS cert already exists for API")
-				commonName, err := secrets.GetCertCommonName(secret.Data[corev1.TLSCertKey])
-				if err != nil {
-					return err
-				}
-				if commonName != fmt.Sprintf("api.%s", relocation.Spec.Domain) {
-					logger.Info("Domain name has changed, generating new TLS certificate for API")
-					var err error
-					secret.Data, err = secrets.GenerateTLSKeyPair(ctx, c, relocation.Spec.Domain, "api")
-					if err != nil {
-						return err
-					}
-				}
 			}
 			secret.Type = corev1.SecretTypeTLS
 			// Set the controller as the owner so that the secret is deleted along with the CR
@@ -65,21 +92,31 @@ func Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, rel
 		if op != controllerutil.OperationResultNone {
 			logger.Info("API TLS cert modified", "OperationResult", op)
 		}
+		if err := secrets.RecordCertificate(relocation, rhsysenggithubiov1.CertificateAPI, secret, generation.GetRenewBefore()); err != nil {
+			return err
+		}
 	} else {
-		if relocation.Spec.APICertRef.Name == "" || relocation.Spec.APICertRef.Namespace == "" {
+		if certRef.Name == "" || certRef.Namespace == "" {
 			return fmt.Errorf("must specify secret name and namespace")
 		}
-		if err := secrets.ValidateSecretType(ctx, c, relocation.Spec.APICertRef, corev1.SecretTypeTLS); err != nil {
-			return err
+		// the certificate is checked before it is applied, since the API server would not be able to serve it
+		for _, v := range hostnames {
+			if err := secrets.ValidateTLSSecret(ctx, c, certRef, v); err != nil {
+				return err
+			}
 		}
 
-		origSecretName = relocation.Spec.APICertRef.Name
-		origSecretNamespace = relocation.Spec.APICertRef.Namespace
-		logger.Info("Using user provided API certificate", "namespace", origSecretNamespace, "name", origSecretName)
+		origSecretName = certRef.Name
+		origSecretNamespace = certRef.Namespace
+		if relocation.Spec.APICertRef != nil {
+			// the user provided certificate is not renewed by us
+			secrets.ForgetCertificate(relocation, rhsysenggithubiov1.CertificateAPI)
+			logger.Info("Using user provided API certificate", "namespace", origSecretNamespace, "name", origSecretName)
+		}
 
 		// The certificate must be in the openshift-config namespace
 		// so if their certificate is in another namespace, we copy it
-		if origSecretNamespace != rhsysenggithubiov1beta1.ConfigNamespace {
+		if origSecretNamespace != rhsysenggithubiov1.ConfigNamespace {
 			secretName := "copied-api-secret"
 			// Copy the secret into the openshift-config namespace
 			// the original may be owned by another controller (cert-manager for example)
@@ -91,25 +128,39 @@ func Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, rel
 				OwnDestination:               true,
 				DestinationOwnedByController: true,
 			}
-			op, err := secrets.CopySecret(ctx, c, relocation, scheme, origSecretName, origSecretNamespace, secretName, rhsysenggithubiov1beta1.ConfigNamespace, copySettings)
+			op, err := secrets.CopySecret(ctx, c, relocation, scheme, origSecretName, origSecretNamespace, secretName, rhsysenggithubiov1.ConfigNamespace, copySettings)
 			if err != nil {
 				return err
 			}
 			if op != controllerutil.OperationResultNone {
-				logger.Info(fmt.Sprintf("User provided API cert copied to %s", rhsysenggithubiov1beta1.ConfigNamespace), "OperationResult", op)
+				logger.Info(fmt.Sprintf("User provided API cert copied to %s", rhsysenggithubiov1.ConfigNamespace), "OperationResult", op)
 			}
 			origSecretName = secretName
 		}
 	}
 
+	// the entry that we added previously, which may be for another domain or secret
+	applied := configv1.APIServerNamedServingCert{}
+	appliedFound, err := backup.Restore(ctx, c, backup.AppliedAPIServerNamedCertificateKey, &applied)
+	if err != nil {
+		return err
+	}
+
+	namedCertificate := configv1.APIServerNamedServingCert{
+		Names:              hostnames,
+		ServingCertificate: configv1.SecretNameReference{Name: origSecretName},
+	}
 	apiServer := &configv1.APIServer{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}}
 	op, err := controllerutil.CreateOrPatch(ctx, c, apiServer, func() error {
-		apiServer.Spec.ServingCerts.NamedCertificates = []configv1.APIServerNamedServingCert{
-			{
-				Names:              []string{fmt.Sprintf("api.%s", relocation.Spec.Domain)},
-				ServingCertificate: configv1.SecretNameReference{Name: origSecretName},
-			},
+		// our entry comes first, so that it takes precedence over the named certificates of the cluster owner
+		merged := []configv1.APIServerNamedServingCert{namedCertificate}
+		for _, v := range apiServer.Spec.ServingCerts.NamedCertificates {
+			if equality.Semantic.DeepEqual(v, namedCertificate) || (appliedFound && equality.Semantic.DeepEqual(v, applied)) {
+				continue
+			}
+			merged = append(merged, v)
 		}
+		apiServer.Spec.ServingCerts.NamedCertificates = merged
 		return nil
 	})
 	if err != nil {
@@ -118,15 +169,71 @@ func Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, rel
 	if op != controllerutil.OperationResultNone {
 		logger.Info("APIServer modified", "OperationResult", op)
 	}
+	// keep track of our entry, so that only this entry is removed when it changes, or when the CR is deleted
+	if err := backup.SaveApplied(ctx, c, scheme, relocation, backup.AppliedAPIServerNamedCertificateKey, namedCertificate); err != nil {
+		return err
+	}
+
+	if relocation.Spec.APICertRef != nil || relocation.Spec.CertManager == nil {
+		// the certificate is no longer requested from cert-manager, and the APIServer no longer refers to it
+		return certmanager.Cleanup(ctx, c, rhsysenggithubiov1.CertificateAPI, nil)
+	}
 	return nil
 }
 
+// Hostnames returns the hostnames that the API server is served on with the relocated certificate:
+// api.<domain> first, then the additional names, and api.<original domain> if it is kept
+func Hostnames(ctx context.Context, c client.Client, relocation *rhsysenggithubiov1.ClusterRelocation) ([]string, error) {
+	hostnames := []string{fmt.Sprintf("api.%s", relocation.Spec.Domain)}
+	additionalNames := relocation.Spec.API.GetAdditionalNames()
+	if relocation.Spec.API.GetKeepOriginalDomain() {
+		baseDomain, err := util.GetBaseDomain(ctx, c)
+		if err != nil {
+			return nil, err
+		}
+		additionalNames = append(append([]string{}, additionalNames...), fmt.Sprintf("api.%s", baseDomain))
+	}
+	for _, v := range additionalNames {
+		duplicate := false
+		for _, w := range hostnames {
+			if w == v {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			hostnames = append(hostnames, v)
+		}
+	}
+	return hostnames, nil
+}
+
 func Cleanup(ctx context.Context, c client.Client, logger logr.Logger) error {
 	// We modified the APIServer resource, but we don't own it
-	// Therefore, we need to use a finalizer to put it back the way we found it if the CR is deleted
+	// Therefore, we need to use a finalizer to remove our named certificate if the CR is deleted
+	applied := configv1.APIServerNamedServingCert{}
+	appliedFound, err := backup.Restore(ctx, c, backup.AppliedAPIServerNamedCertificateKey, &applied)
+	if err != nil {
+		return err
+	}
+	if !appliedFound {
+		// if there is no backup, that means we didn't modify the APIServer. Nothing for us to do
+		return nil
+	}
+
 	apiServer := &configv1.APIServer{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}}
 	op, err := controllerutil.CreateOrPatch(ctx, c, apiServer, func() error {
-		apiServer.Spec.ServingCerts.NamedCertificates = nil
+		// the other named certificates may have been modified by the cluster owner in the meantime, so only our entry is removed
+		namedCertificates := []configv1.APIServerNamedServingCert{}
+		for _, v := range apiServer.Spec.ServingCerts.NamedCertificates {
+			if !equality.Semantic.DeepEqual(v, applied) {
+				namedCertificates = append(namedCertificates, v)
+			}
+		}
+		if len(namedCertificates) == 0 {
+			namedCertificates = nil
+		}
+		apiServer.Spec.ServingCerts.NamedCertificates = namedCertificates
 		return nil
 	})
 	if err != nil {
@@ -135,5 +242,5 @@ func Cleanup(ctx context.Context, c client.Client, logger logr.Logger) error {
 	if op != controllerutil.OperationResultNone {
 		logger.Info("APIServer reverted to original state", "OperationResult", op)
 	}
-	return nil
+	return backup.Remove(ctx, c, backup.AppliedAPIServerNamedCertificateKey)
 }
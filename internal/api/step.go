@@ -0,0 +1,65 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	rhsysenggithubiov1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/certmanager"
+	secrets "github.com/RHsyseng/cluster-relocation-operator/internal/secrets"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/step"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/util"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/verify"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func init() {
+	step.Register(step.OrderAPI, apiStep{})
+}
+
+type apiStep struct{}
+
+func (apiStep) Name() string { return rhsysenggithubiov1.StepAPI }
+
+func (apiStep) ConditionType() string { return rhsysenggithubiov1.ConditionTypeAPIReady }
+
+func (apiStep) FailureReason() string { return rhsysenggithubiov1.APIReconciliationFailedReason }
+
+func (apiStep) Enabled(relocation *rhsysenggithubiov1.ClusterRelocation) bool {
+	return true
+}
+
+// Applies a new certificate and domain alias to the API server, then waits for it to be served
+func (apiStep) Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
+	if err := Reconcile(ctx, c, scheme, relocation, logger); err != nil {
+		return err
+	}
+	roots, err := verify.ExpectedCA(ctx, c, relocation, certmanager.CertRef(relocation, rhsysenggithubiov1.CertificateAPI, relocation.Spec.APICertRef))
+	if err != nil {
+		return err
+	}
+	hostnames, err := Hostnames(ctx, c, relocation)
+	if err != nil {
+		return err
+	}
+	return verify.API(ctx, c, relocation, logger, hostnames, roots)
+}
+
+// Reverts the API server, then waits for the original domain to be served
+func (apiStep) Cleanup(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
+	if err := Cleanup(ctx, c, logger); err != nil {
+		return err
+	}
+	if err := certmanager.Cleanup(ctx, c, rhsysenggithubiov1.CertificateAPI, nil); err != nil {
+		return err
+	}
+	secrets.ForgetCertificate(relocation, rhsysenggithubiov1.CertificateAPI)
+	baseDomain, err := util.GetBaseDomain(ctx, c)
+	if err != nil {
+		return err
+	}
+	// the original certificate may be signed by any CA, so only its names and expiry are verified
+	return verify.API(ctx, c, relocation, logger, []string{fmt.Sprintf("api.%s", baseDomain)}, nil)
+}
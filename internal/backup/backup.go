@@ -0,0 +1,166 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	rhsysenggithubiov1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=create;update;get;list;watch
+
+// ConfigMapName is the name of the ConfigMap which holds the original values of the fields modified by the operator.
+// It is owned by the ClusterRelocation, so that it is deleted along with the CR once the original values have been restored.
+const ConfigMapName = "relocation-backup"
+
+// Keys of the backup ConfigMap. Each one holds the JSON encoded original value of a field of a resource that we modify, but don't own
+const (
+	IngressAppsDomainKey        = "ingress.cluster.appsDomain"
+	IngressComponentRoutesKey   = "ingress.cluster.componentRoutes"
+	ImageAdditionalTrustedCAKey = "image.cluster.additionalTrustedCA"
+	DNSServersKey               = "dns.default.servers"
+	ProxyTrustedCAKey           = "proxy.cluster.trustedCA"
+)
+
+// IngressControllerDefaultCertificateKeyFor returns the key which holds the original default certificate of the named IngressController
+func IngressControllerDefaultCertificateKeyFor(name string) string {
+	return fmt.Sprintf("ingresscontroller.%s.defaultCertificate", name)
+}
+
+// Keys of the backup ConfigMap which hold the JSON encoded value that we applied to a field, rather than its original value.
+// They are used for the fields which are shared with the cluster owner (e.g. an entry of a list), so that only our value is removed.
+const (
+	AppliedAPIServerNamedCertificateKey = "apiserver.cluster.namedCertificates.applied"
+	AppliedIngressComponentRoutesKey    = "ingress.cluster.componentRoutes.applied"
+)
+
+// Save stores the original value of a field, before we modify it for the first time.
+// If a value has already been saved under this key, it is kept as is,
+// so that subsequent reconciles don't overwrite the original value with the one that we applied.
+func Save(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, key string, original interface{}) error {
+	_, err := save(ctx, c, scheme, relocation, key, original)
+	return err
+}
+
+// SaveOriginal stores the original value of a field like Save, unless relocated is true, which means that the field already holds values applied by the operator.
+// This happens on the clusters relocated by previous versions of the operator, which didn't save the original values.
+// The original value is unknown in that case, so known (the current value without the values that we applied) is saved instead, and this is logged.
+func SaveOriginal(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger,
+	key string, current interface{}, known interface{}, relocated bool) error {
+	if !relocated {
+		return Save(ctx, c, scheme, relocation, key, current)
+	}
+	saved, err := save(ctx, c, scheme, relocation, key, known)
+	if err != nil {
+		return err
+	}
+	if saved {
+		logger.Info("The original value is unknown, since it was already modified by the operator. The values that the operator applied won't be restored", "key", key)
+	}
+	return nil
+}
+
+// stores value under key if no value has been saved yet, and returns true if it was stored
+func save(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, key string, value interface{}) (bool, error) {
+	saved := false
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: ConfigMapName, Namespace: rhsysenggithubiov1.ConfigNamespace}}
+	_, err := controllerutil.CreateOrUpdate(ctx, c, configMap, func() error {
+		if _, ok := configMap.Data[key]; !ok {
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			if configMap.Data == nil {
+				configMap.Data = map[string]string{}
+			}
+			configMap.Data[key] = string(encoded)
+			saved = true
+		}
+		// Set the controller as the owner so that the ConfigMap is deleted along with the CR
+		return controllerutil.SetControllerReference(relocation, configMap, scheme)
+	})
+	return saved, err
+}
+
+// SaveApplied stores the value that we applied to a field, replacing the value saved by a previous reconcile,
+// so that the value can be found and removed from the field when it changes, or when the CR is deleted.
+func SaveApplied(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, key string, applied interface{}) error {
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: ConfigMapName, Namespace: rhsysenggithubiov1.ConfigNamespace}}
+	_, err := controllerutil.CreateOrUpdate(ctx, c, configMap, func() error {
+		value, err := json.Marshal(applied)
+		if err != nil {
+			return err
+		}
+		if configMap.Data == nil {
+			configMap.Data = map[string]string{}
+		}
+		configMap.Data[key] = string(value)
+		// Set the controller as the owner so that the ConfigMap is deleted along with the CR
+		return controllerutil.SetControllerReference(relocation, configMap, scheme)
+	})
+	return err
+}
+
+// Restore decodes the original value of a field into original.
+// It returns false if no value was saved under this key, which means that we never modified the field.
+func Restore(ctx context.Context, c client.Client, key string, original interface{}) (bool, error) {
+	configMap := &corev1.ConfigMap{}
+	if err := c.Get(ctx, types.NamespacedName{Name: ConfigMapName, Namespace: rhsysenggithubiov1.ConfigNamespace}, configMap); err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	value, ok := configMap.Data[key]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal([]byte(value), original)
+}
+
+// List returns the keys of the saved values which start with prefix, in sorted order
+func List(ctx context.Context, c client.Client, prefix string) ([]string, error) {
+	configMap := &corev1.ConfigMap{}
+	if err := c.Get(ctx, types.NamespacedName{Name: ConfigMapName, Namespace: rhsysenggithubiov1.ConfigNamespace}, configMap); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	keys := []string{}
+	for k := range configMap.Data {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Remove deletes the value saved under this key, once it has been restored.
+// This way, if the field is modified again (e.g. the user removes and then re-adds a section of the spec), a fresh backup is taken.
+func Remove(ctx context.Context, c client.Client, key string) error {
+	configMap := &corev1.ConfigMap{}
+	if err := c.Get(ctx, types.NamespacedName{Name: ConfigMapName, Namespace: rhsysenggithubiov1.ConfigNamespace}, configMap); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if _, ok := configMap.Data[key]; !ok {
+		return nil
+	}
+	delete(configMap.Data, key)
+	return c.Update(ctx, configMap)
+}
@@ -0,0 +1,171 @@
+package ca
+
+import (
+	"context"
+	"fmt"
+
+	rhsysenggithubiov1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/backup"
+	secrets "github.com/RHsyseng/cluster-relocation-operator/internal/secrets"
+	"github.com/go-logr/logr"
+	configv1 "github.com/openshift/api/config/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=create;update;delete;get;list;watch
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=create;update;delete;get;list;watch
+//+kubebuilder:rbac:groups=config.openshift.io,resources=proxies,verbs=patch;get;list;watch
+
+// ConfigMapName is the name of the ConfigMap in openshift-config which publishes the relocation CA.
+// ca.crt only holds the relocation CA, and ca-bundle.crt also holds the original trusted CA bundle of the proxy
+const ConfigMapName = "relocation-ca"
+
+const (
+	caCertKey   = "ca.crt"
+	caBundleKey = "ca-bundle.crt"
+)
+
+func Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
+	if relocation.Spec.CertificateGeneration.GetCAType() == rhsysenggithubiov1.CATypeManaged {
+		secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: secrets.ManagedCASecretName, Namespace: rhsysenggithubiov1.ConfigNamespace}}
+		op, err := controllerutil.CreateOrUpdate(ctx, c, secret, func() error {
+			// the CA is only generated once, so that the clients which trust it keep doing so
+			if _, ok := secret.Data[corev1.TLSCertKey]; !ok {
+				logger.Info("generating new relocation CA")
+				var err error
+				secret.Data, err = secrets.GenerateCA("cluster-relocation-ca", relocation.Spec.CertificateGeneration)
+				if err != nil {
+					return err
+				}
+			}
+			secret.Type = corev1.SecretTypeTLS
+			// Set the controller as the owner so that the secret is deleted along with the CR
+			return controllerutil.SetControllerReference(relocation, secret, scheme)
+		})
+		if err != nil {
+			return err
+		}
+		if op != controllerutil.OperationResultNone {
+			logger.Info("Relocation CA modified", "OperationResult", op)
+		}
+	} else if err := deleteManagedCA(ctx, c, relocation, logger); err != nil {
+		return err
+	}
+
+	signer, err := secrets.GetSigner(ctx, c, relocation)
+	if err != nil {
+		return err
+	}
+
+	proxy := &configv1.Proxy{}
+	if err := c.Get(ctx, types.NamespacedName{Name: "cluster"}, proxy); err != nil {
+		return err
+	}
+	// save the original trusted CA, so that it can be restored when the CR is deleted
+	if err := backup.Save(ctx, c, scheme, relocation, backup.ProxyTrustedCAKey, proxy.Spec.TrustedCA); err != nil {
+		return err
+	}
+	// the original trusted CA bundle is kept in our bundle, since the proxy can only reference one ConfigMap
+	originalTrustedCA := configv1.ConfigMapNameReference{}
+	if _, err := backup.Restore(ctx, c, backup.ProxyTrustedCAKey, &originalTrustedCA); err != nil {
+		return err
+	}
+	caBundle := string(signer.CertificatePEM)
+	if originalTrustedCA.Name != "" && originalTrustedCA.Name != ConfigMapName {
+		originalConfigMap := &corev1.ConfigMap{}
+		if err := c.Get(ctx, types.NamespacedName{Name: originalTrustedCA.Name, Namespace: rhsysenggithubiov1.ConfigNamespace}, originalConfigMap); err != nil {
+			return err
+		}
+		if originalBundle := originalConfigMap.Data[caBundleKey]; originalBundle != "" {
+			caBundle = fmt.Sprintf("%s\n%s", originalBundle, caBundle)
+		}
+	}
+
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: ConfigMapName, Namespace: rhsysenggithubiov1.ConfigNamespace}}
+	op, err := controllerutil.CreateOrUpdate(ctx, c, configMap, func() error {
+		configMap.Data = map[string]string{
+			caCertKey:   string(signer.CertificatePEM),
+			caBundleKey: caBundle,
+		}
+		// Set the controller as the owner so that the ConfigMap is deleted along with the CR
+		return controllerutil.SetControllerReference(relocation, configMap, scheme)
+	})
+	if err != nil {
+		return err
+	}
+	if op != controllerutil.OperationResultNone {
+		logger.Info("Relocation CA bundle modified", "OperationResult", op)
+	}
+
+	op, err = controllerutil.CreateOrPatch(ctx, c, proxy, func() error {
+		proxy.Spec.TrustedCA = configv1.ConfigMapNameReference{Name: ConfigMapName}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if op != controllerutil.OperationResultNone {
+		logger.Info("Proxy trusted CA modified", "OperationResult", op)
+	}
+	return nil
+}
+
+// We modified the cluster Proxy, but we don't own it
+// Therefore, we need to put it back the way we found it when the CA is no longer used, or if the CR is deleted
+func Cleanup(ctx context.Context, c client.Client, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
+	trustedCA := configv1.ConfigMapNameReference{}
+	found, err := backup.Restore(ctx, c, backup.ProxyTrustedCAKey, &trustedCA)
+	if err != nil {
+		return err
+	}
+	if found {
+		proxy := &configv1.Proxy{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}}
+		op, err := controllerutil.CreateOrPatch(ctx, c, proxy, func() error {
+			proxy.Spec.TrustedCA = trustedCA
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if op != controllerutil.OperationResultNone {
+			logger.Info("Proxy trusted CA reverted to original state", "OperationResult", op)
+		}
+		if err := backup.Remove(ctx, c, backup.ProxyTrustedCAKey); err != nil {
+			return err
+		}
+	}
+
+	// the ConfigMap is only deleted once the proxy no longer references it
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: ConfigMapName, Namespace: rhsysenggithubiov1.ConfigNamespace}}
+	if err := c.Delete(ctx, configMap); err != nil && !errors.IsNotFound(err) {
+		return err
+	} else if err == nil {
+		logger.Info("Relocation CA bundle deleted")
+	}
+	return deleteManagedCA(ctx, c, relocation, logger)
+}
+
+// deletes the CA created by the operator. A secret with the same name which isn't controlled by the CR (e.g. a user provided CA) is kept
+func deleteManagedCA(ctx context.Context, c client.Client, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{Name: secrets.ManagedCASecretName, Namespace: rhsysenggithubiov1.ConfigNamespace}, secret); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if !metav1.IsControlledBy(secret, relocation) {
+		return nil
+	}
+	if err := c.Delete(ctx, secret); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	logger.Info("Relocation CA deleted")
+	return nil
+}
@@ -0,0 +1,38 @@
+package ca
+
+import (
+	"context"
+
+	rhsysenggithubiov1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/step"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func init() {
+	step.Register(step.OrderCA, caStep{})
+}
+
+type caStep struct{}
+
+func (caStep) Name() string { return rhsysenggithubiov1.StepCA }
+
+func (caStep) ConditionType() string { return rhsysenggithubiov1.ConditionTypeCAReady }
+
+func (caStep) FailureReason() string { return rhsysenggithubiov1.CAReconciliationFailedReason }
+
+// The loadbalancer-serving-signer is managed by the cluster, so there is nothing to publish for it
+func (caStep) Enabled(relocation *rhsysenggithubiov1.ClusterRelocation) bool {
+	return relocation.Spec.CertificateGeneration.GetCAType() != rhsysenggithubiov1.CATypeLoadBalancerServingSigner
+}
+
+// Creates the relocation CA if it is managed by the operator, then publishes it and adds it to the trusted CA bundle of the proxy.
+// This runs before the Ingress and API steps, which sign the generated certificates with it
+func (caStep) Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
+	return Reconcile(ctx, c, scheme, relocation, logger)
+}
+
+func (caStep) Cleanup(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
+	return Cleanup(ctx, c, relocation, logger)
+}
@@ -3,7 +3,7 @@ package catalog
 import (
 	"context"
 
-	rhsysenggithubiov1beta1 "github.com/RHsyseng/cluster-relocation-operator/api/v1beta1"
+	rhsysenggithubiov1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
 	"github.com/go-logr/logr"
 	operatorhubv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
@@ -18,7 +18,7 @@ import (
 
 const marketplaceNamespaceName = "openshift-marketplace"
 
-func Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1beta1.ClusterRelocation, logger logr.Logger) error {
+func Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
 	if err := Cleanup(ctx, c, relocation, logger); err != nil {
 		return err
 	}
@@ -51,7 +51,13 @@ func Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, rel
 		op, err := controllerutil.CreateOrUpdate(ctx, c, catalogSource, func() error {
 			catalogSource.Spec.Image = v.Image
 			catalogSource.Spec.SourceType = operatorhubv1alpha1.SourceTypeGrpc
-			catalogSource.Spec.UpdateStrategy.RegistryPoll = &operatorhubv1alpha1.RegistryPoll{RawInterval: "24h"}
+			catalogSource.Spec.DisplayName = v.DisplayName
+			catalogSource.Spec.Publisher = v.Publisher
+			registryPollInterval := v.RegistryPollInterval
+			if registryPollInterval == "" {
+				registryPollInterval = rhsysenggithubiov1.DefaultRegistryPollInterval
+			}
+			catalogSource.Spec.UpdateStrategy = &operatorhubv1alpha1.UpdateStrategy{RegistryPoll: &operatorhubv1alpha1.RegistryPoll{RawInterval: registryPollInterval}}
 			// Set the controller as the owner so that the CatalogSource is deleted along with the CR
 			return controllerutil.SetControllerReference(relocation, catalogSource, scheme)
 		})
@@ -65,16 +71,15 @@ func Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, rel
 	return nil
 }
 
-func Cleanup(ctx context.Context, c client.Client, relocation *rhsysenggithubiov1beta1.ClusterRelocation, logger logr.Logger) error {
+func Cleanup(ctx context.Context, c client.Client, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
 	// if they remove something from relocation.Spec.CatalogSources, we need to clean it up
 	catalogSources := &operatorhubv1alpha1.CatalogSourceList{}
 	if err := c.List(ctx, catalogSources, client.InNamespace(marketplaceNamespaceName)); err != nil {
 		return err
 	}
 	for _, v := range catalogSources.Items { // loop through all existing CatalogSources
-		if len(v.ObjectMeta.OwnerReferences) > 0 &&
-			v.ObjectMeta.OwnerReferences[0].APIVersion == relocation.APIVersion &&
-			v.ObjectMeta.OwnerReferences[0].Kind == relocation.Kind { // check if we own this CatalogSource
+		// check if we own this CatalogSource. The owner is matched by UID, since the CatalogSource may have been created by an older version of the API
+		if metav1.IsControlledBy(&v, relocation) {
 			var existsInSpec bool
 
 			for _, w := range relocation.Spec.CatalogSources { // check if the current Spec wants this CatalogSource
@@ -0,0 +1,37 @@
+package catalog
+
+import (
+	"context"
+
+	rhsysenggithubiov1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/step"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func init() {
+	step.Register(step.OrderCatalog, catalogStep{})
+}
+
+type catalogStep struct{}
+
+func (catalogStep) Name() string { return rhsysenggithubiov1.StepCatalog }
+
+func (catalogStep) ConditionType() string { return rhsysenggithubiov1.ConditionTypeCatalogReady }
+
+func (catalogStep) FailureReason() string {
+	return rhsysenggithubiov1.CatalogReconciliationFailedReason
+}
+
+func (catalogStep) Enabled(relocation *rhsysenggithubiov1.ClusterRelocation) bool {
+	return relocation.Spec.CatalogSources != nil
+}
+
+func (catalogStep) Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
+	return Reconcile(ctx, c, scheme, relocation, logger)
+}
+
+func (catalogStep) Cleanup(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
+	return Cleanup(ctx, c, relocation, logger)
+}
@@ -0,0 +1,215 @@
+package certmanager
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	rhsysenggithubiov1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
+	secrets "github.com/RHsyseng/cluster-relocation-operator/internal/secrets"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/step"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+//+kubebuilder:rbac:groups=cert-manager.io,resources=certificates,verbs=create;update;delete;get;list;watch
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=delete;get;list;watch
+
+// CertificateLabel is set on the Certificates created by the operator, and on their secrets.
+// It holds the name of the certificate (API or Ingress), so that they can be found again when the settings change
+const CertificateLabel = "rhsyseng.github.io/certificate"
+
+// the cert-manager API is used through unstructured objects, so that cert-manager doesn't have to be installed unless it is used
+var certificateGVK = schema.GroupVersionKind{Group: rhsysenggithubiov1.CertManagerGroup, Version: "v1", Kind: "Certificate"}
+
+// SecretRef returns the secret which cert-manager stores the named certificate in
+func SecretRef(relocation *rhsysenggithubiov1.ClusterRelocation, name string) *corev1.SecretReference {
+	return &corev1.SecretReference{
+		Name:      fmt.Sprintf("relocation-%s", strings.ToLower(name)),
+		Namespace: relocation.Spec.CertManager.GetNamespace(),
+	}
+}
+
+// CertRef returns certRef if it is set. Otherwise, it returns the secret of the named certificate if it is requested from cert-manager,
+// or nil if it is generated by the operator
+func CertRef(relocation *rhsysenggithubiov1.ClusterRelocation, name string, certRef *corev1.SecretReference) *corev1.SecretReference {
+	if certRef != nil || relocation.Spec.CertManager == nil {
+		return certRef
+	}
+	return SecretRef(relocation, name)
+}
+
+// Reconcile requests a certificate for commonName and the additional names (DNS names or IP addresses) from cert-manager,
+// and returns the secret that it is stored in once it has been issued. The certificate is renewed by cert-manager.
+// Returns a WaitingError until the Certificate is Ready
+func Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger,
+	name string, commonName string, additionalNames []string) (*corev1.SecretReference, error) {
+	secretRef := SecretRef(relocation, name)
+
+	dnsNames := []interface{}{commonName}
+	ipAddresses := []interface{}{}
+	seen := map[string]bool{commonName: true}
+	for _, v := range additionalNames {
+		if ip := net.ParseIP(v); ip != nil {
+			v = ip.String()
+		}
+		if seen[v] {
+			continue
+		}
+		seen[v] = true
+		if net.ParseIP(v) != nil {
+			ipAddresses = append(ipAddresses, v)
+		} else {
+			dnsNames = append(dnsNames, v)
+		}
+	}
+
+	generation := relocation.Spec.CertificateGeneration
+	issuerRef := relocation.Spec.CertManager.IssuerRef
+	usages := []interface{}{"server auth", "digital signature"}
+	if generation.GetKeyAlgorithm() == rhsysenggithubiov1.KeyAlgorithmRSA {
+		// RSA keys are also used for key exchange by the older TLS cipher suites
+		usages = append(usages, "key encipherment")
+	}
+	certificate := newCertificate(secretRef.Name, secretRef.Namespace)
+	op, err := controllerutil.CreateOrUpdate(ctx, c, certificate, func() error {
+		labels := certificate.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[CertificateLabel] = name
+		certificate.SetLabels(labels)
+
+		spec := map[string]interface{}{
+			"secretName": secretRef.Name,
+			"secretTemplate": map[string]interface{}{
+				"labels": map[string]interface{}{CertificateLabel: name},
+			},
+			"dnsNames":    dnsNames,
+			"duration":    generation.GetValidity().String(),
+			"renewBefore": generation.GetRenewBefore().String(),
+			"privateKey": map[string]interface{}{
+				"algorithm":      string(generation.GetKeyAlgorithm()),
+				"size":           int64(generation.GetKeySize()),
+				"rotationPolicy": "Always",
+			},
+			"usages": usages,
+			"issuerRef": map[string]interface{}{
+				"name":  issuerRef.Name,
+				"kind":  issuerRef.GetKind(),
+				"group": issuerRef.GetGroup(),
+			},
+		}
+		if len(ipAddresses) > 0 {
+			spec["ipAddresses"] = ipAddresses
+		}
+		if err := unstructured.SetNestedField(certificate.Object, spec, "spec"); err != nil {
+			return err
+		}
+		// Set the controller as the owner so that the Certificate is deleted along with the CR
+		return controllerutil.SetControllerReference(relocation, certificate, scheme)
+	})
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil, fmt.Errorf("cert-manager is not installed: %w", err)
+		}
+		return nil, err
+	}
+	if op != controllerutil.OperationResultNone {
+		logger.Info(fmt.Sprintf("cert-manager Certificate for %s modified", name), "OperationResult", op)
+	}
+
+	ready, message := isReady(certificate)
+	if !ready {
+		logger.Info(fmt.Sprintf("Waiting for cert-manager to issue the certificate for %s", name), "message", message)
+		return nil, step.Wait("cert-manager to issue Certificate %s/%s", secretRef.Namespace, secretRef.Name).WithTimeout(relocation.Spec.Timeouts.GetCertificateIssuance())
+	}
+
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{Name: secretRef.Name, Namespace: secretRef.Namespace}, secret); err != nil {
+		return nil, err
+	}
+	if err := secrets.RecordCertificate(relocation, name, secret, generation.GetRenewBefore()); err != nil {
+		return nil, err
+	}
+
+	// the Certificates of the previous settings (e.g. in another namespace) are only deleted once the new certificate can replace them
+	if err := Cleanup(ctx, c, name, secretRef); err != nil {
+		return nil, err
+	}
+	return secretRef, nil
+}
+
+// Cleanup deletes the Certificates for the named certificate, along with their secrets, except for the one stored in keep.
+// If keep is nil, all of them are deleted
+func Cleanup(ctx context.Context, c client.Client, name string, keep *corev1.SecretReference) error {
+	certificates := &unstructured.UnstructuredList{}
+	certificates.SetGroupVersionKind(certificateGVK.GroupVersion().WithKind(certificateGVK.Kind + "List"))
+	if err := c.List(ctx, certificates, client.MatchingLabels{CertificateLabel: name}); err != nil {
+		if meta.IsNoMatchError(err) {
+			// cert-manager is not installed, so there are no Certificates to delete
+			return nil
+		}
+		return err
+	}
+	for _, v := range certificates.Items {
+		if keep != nil && v.GetName() == keep.Name && v.GetNamespace() == keep.Namespace {
+			continue
+		}
+		certificate := v
+		if err := c.Delete(ctx, &certificate); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	// cert-manager doesn't delete the secrets of the Certificates, unless it is configured to
+	secrets := &corev1.SecretList{}
+	if err := c.List(ctx, secrets, client.MatchingLabels{CertificateLabel: name}); err != nil {
+		return err
+	}
+	for _, v := range secrets.Items {
+		if keep != nil && v.Name == keep.Name && v.Namespace == keep.Namespace {
+			continue
+		}
+		secret := v
+		if err := c.Delete(ctx, &secret); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newCertificate(name string, namespace string) *unstructured.Unstructured {
+	certificate := &unstructured.Unstructured{}
+	certificate.SetGroupVersionKind(certificateGVK)
+	certificate.SetName(name)
+	certificate.SetNamespace(namespace)
+	return certificate
+}
+
+// returns true if the Ready condition of the Certificate is True for its current spec, along with the message of the condition
+func isReady(certificate *unstructured.Unstructured) (bool, string) {
+	conditions, _, _ := unstructured.NestedSlice(certificate.Object, "status", "conditions")
+	for _, v := range conditions {
+		condition, ok := v.(map[string]interface{})
+		if !ok || condition["type"] != "Ready" {
+			continue
+		}
+		message, _ := condition["message"].(string)
+		// the condition refers to the previous spec until cert-manager has observed the update
+		if observedGeneration, found, _ := unstructured.NestedInt64(condition, "observedGeneration"); found && observedGeneration != certificate.GetGeneration() {
+			return false, "the Certificate has not been observed by cert-manager yet"
+		}
+		return condition["status"] == string(metav1.ConditionTrue), message
+	}
+	return false, "the Certificate has no Ready condition yet"
+}
@@ -0,0 +1,108 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	rhsysenggithubiov1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/backup"
+	"github.com/go-logr/logr"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+//+kubebuilder:rbac:groups=operator.openshift.io,resources=dnses,verbs=patch;get;list;watch
+
+// The servers that we add to the cluster DNS are named relocation-<index>.
+// Server names must be valid service names (rfc6335), so they are limited to 15 characters
+const serverPrefix = "relocation-"
+
+// ReconcileClusterDNS adds forwarding zones to the cluster DNS operator (dns.operator.openshift.io/default),
+// for the new base domain and for the Forwarders of the spec.
+// Unlike the internal DNS entries, this doesn't require a reboot, but it only affects the pods which use the cluster DNS.
+func ReconcileClusterDNS(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
+	servers := []operatorv1.Server{}
+	if upstreams := relocation.Spec.DNS.ClusterDNS.Upstreams; len(upstreams) > 0 {
+		servers = append(servers, operatorv1.Server{
+			Zones:         []string{relocation.Spec.Domain},
+			ForwardPlugin: operatorv1.ForwardPlugin{Upstreams: upstreams},
+		})
+	}
+	for _, v := range relocation.Spec.DNS.Forwarders {
+		servers = append(servers, operatorv1.Server{
+			Zones:         []string{v.Zone},
+			ForwardPlugin: operatorv1.ForwardPlugin{Upstreams: v.Upstreams},
+		})
+	}
+	for i := range servers {
+		servers[i].Name = fmt.Sprintf("%s%d", serverPrefix, i)
+	}
+
+	dns := &operatorv1.DNS{}
+	if err := c.Get(ctx, types.NamespacedName{Name: "default"}, dns); err != nil {
+		return err
+	}
+	// save the original servers, so that they can be restored when the CR is deleted.
+	// Our servers are never part of the original value
+	known := []operatorv1.Server{}
+	for _, v := range dns.Spec.Servers {
+		if !strings.HasPrefix(v.Name, serverPrefix) {
+			known = append(known, v)
+		}
+	}
+	if len(known) == 0 {
+		known = nil
+	}
+	if err := backup.SaveOriginal(ctx, c, scheme, relocation, logger, backup.DNSServersKey, dns.Spec.Servers, known, len(known) != len(dns.Spec.Servers)); err != nil {
+		return err
+	}
+	op, err := controllerutil.CreateOrPatch(ctx, c, dns, func() error {
+		// keep the servers which were added by the user, and replace ours
+		newServers := []operatorv1.Server{}
+		for _, v := range dns.Spec.Servers {
+			if !strings.HasPrefix(v.Name, serverPrefix) {
+				newServers = append(newServers, v)
+			}
+		}
+		dns.Spec.Servers = append(newServers, servers...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if op != controllerutil.OperationResultNone {
+		logger.Info("Cluster DNS forwarding zones modified", "OperationResult", op)
+	}
+	return nil
+}
+
+// CleanupClusterDNS restores the original servers of the cluster DNS operator
+func CleanupClusterDNS(ctx context.Context, c client.Client, logger logr.Logger) error {
+	servers := []operatorv1.Server{}
+	found, err := backup.Restore(ctx, c, backup.DNSServersKey, &servers)
+	if err != nil {
+		return err
+	}
+	if !found {
+		// if there is no backup, that means we didn't modify the cluster DNS. Nothing for us to do
+		return nil
+	}
+
+	dns := &operatorv1.DNS{ObjectMeta: metav1.ObjectMeta{Name: "default"}}
+	op, err := controllerutil.CreateOrPatch(ctx, c, dns, func() error {
+		dns.Spec.Servers = servers
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if op != controllerutil.OperationResultNone {
+		logger.Info("Cluster DNS reverted to original state", "OperationResult", op)
+	}
+	return backup.Remove(ctx, c, backup.DNSServersKey)
+}
@@ -0,0 +1,66 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+
+	rhsysenggithubiov1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/preflight"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Preflight looks up api, api-int and a name under *.apps of the new domain, and records the results in the status.
+// It returns an error if one of the required names doesn't resolve to the expected addresses
+func Preflight(ctx context.Context, c client.Client, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
+	var nameservers []string
+	if relocation.Spec.DNS != nil && relocation.Spec.DNS.Preflight != nil {
+		nameservers = relocation.Spec.DNS.Preflight.Nameservers
+	}
+	if len(nameservers) == 0 {
+		var err error
+		nameservers, err = preflight.ClusterDNSNameservers(ctx, c)
+		if err != nil {
+			// the resolver of the operator pod also uses the cluster DNS, through the service name
+			logger.Info("could not find the cluster DNS service, using the default resolver", "error", err.Error())
+		}
+	}
+
+	nodes := &corev1.NodeList{}
+	if err := c.List(ctx, nodes); err != nil {
+		return err
+	}
+	if len(nodes.Items) == 0 {
+		return fmt.Errorf("could not find any nodes")
+	}
+	// api-int is only used by the nodes, so it is only required when we add the internal DNS entries
+	internalDNS := managesNodeDNS(relocation, len(nodes.Items))
+	var apiIPs, ingressIPs []string
+	if internalDNS || (relocation.Spec.DNS != nil && relocation.Spec.DNS.Addresses != nil) {
+		// the addresses are only checked when the operator manages the DNS entries. Otherwise, the external records may point elsewhere (e.g. to a NAT or public IP)
+		var err error
+		apiIPs, ingressIPs, err = getAddresses(ctx, c, relocation, nodes.Items)
+		if err != nil {
+			// the names only need to resolve, since we don't know where they should point to
+			logger.Info("could not find the expected addresses of the new domain, accepting any address", "error", err.Error())
+		}
+	}
+
+	domain := relocation.Spec.Domain
+	// the name under *.apps is derived from the UID of the CR, so that it is unlikely to have a record of its own,
+	// while still being the same on every reconcile
+	id := string(relocation.UID)
+	if len(id) > 8 {
+		id = id[:8]
+	}
+	appsName := fmt.Sprintf("preflight-%s.apps.%s", id, domain)
+	checks := []preflight.Check{
+		{Name: fmt.Sprintf("api.%s", domain), Expected: apiIPs},
+		{Name: fmt.Sprintf("api-int.%s", domain), Expected: apiIPs, Optional: !internalDNS},
+		{Name: appsName, Expected: ingressIPs},
+	}
+
+	relocation.Status.DNSLookups = preflight.LookupDNS(ctx, nameservers, checks)
+	return preflight.Error(relocation.Status.DNSLookups)
+}
@@ -4,14 +4,20 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"time"
+	"net"
+	"sort"
+	"strings"
 
-	rhsysenggithubiov1beta1 "github.com/RHsyseng/cluster-relocation-operator/api/v1beta1"
+	rhsysenggithubiov1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/step"
 	"github.com/go-logr/logr"
+	configv1 "github.com/openshift/api/config/v1"
 	machineconfigurationv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -38,96 +44,265 @@ type MachineConfigData struct {
 //+kubebuilder:rbac:groups="",resources=nodes,verbs=list;watch
 //+kubebuilder:rbac:groups=machineconfiguration.openshift.io,resources=machineconfigs,verbs=create;update;get;list;watch
 //+kubebuilder:rbac:groups=machineconfiguration.openshift.io,resources=machineconfigpools,verbs=get;list;watch
+//+kubebuilder:rbac:groups=config.openshift.io,resources=infrastructures,verbs=get;list;watch
 
-func Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1beta1.ClusterRelocation, logger logr.Logger) error {
+// returned when a multi-node cluster has no API and ingress VIPs, in which case the internal DNS entries can't be added
+var errNoVIPs = errors.New("could not find the API and ingress VIPs of the cluster")
+
+// The MachineConfigs are named relocation-dns-<role>
+const machineConfigPrefix = "relocation-dns-"
+
+// On SNO, the node already uses dnsmasq as its resolver, so the entries are added to its configuration
+const snoDNSMasqPath = "/etc/dnsmasq.d/relocation-domain.conf"
+
+// On multi-node clusters, the dnsmasq plugin of NetworkManager is enabled.
+// NetworkManager then runs dnsmasq as the local resolver, which forwards the other queries to the upstream DNS servers
+const (
+	networkManagerConfPath    = "/etc/NetworkManager/conf.d/relocation-dns.conf"
+	networkManagerDNSMasqPath = "/etc/NetworkManager/dnsmasq.d/relocation-domain.conf"
+)
+
+func Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
 	nodes := &corev1.NodeList{}
 	if err := c.List(ctx, nodes); err != nil {
 		return err
 	}
-	if len(nodes.Items) > 1 {
-		// DNS reconfiguration is only supported on SNO
-		// Relocation will still work on MNO, but external DNS records need to be in place for the new domain
-		logger.Info("DNS reconfiguration not supported on multi-node clusters. Ensure that external DNS records exist for the new domain")
+	if len(nodes.Items) == 0 {
+		return fmt.Errorf("could not find any nodes")
+	}
+	if !managesNodeDNS(relocation, len(nodes.Items)) {
+		// the nodes of multi-node clusters don't run dnsmasq, and enabling it may conflict with the DNS services of the platform
+		logger.Info("DNS reconfiguration not supported on multi-node clusters unless dns.networkManagerDNSMasq is true. Ensure that external DNS records exist for the new domain, or use dns.clusterDNS")
+		return nil
+	}
+
+	apiIPs, ingressIPs, err := getAddresses(ctx, c, relocation, nodes.Items)
+	if errors.Is(err, errNoVIPs) {
+		// Relocation will still work, but external DNS records need to be in place for the new domain
+		logger.Info("DNS reconfiguration not supported on multi-node clusters without VIPs. Ensure that external DNS records exist for the new domain, or set the addresses in the spec", "error", err.Error())
 		return nil
 	}
-	internalIP, err := getInternalIP(nodes.Items[0])
 	if err != nil {
 		return err
 	}
 
-	machineConfig := &machineconfigurationv1.MachineConfig{ObjectMeta: metav1.ObjectMeta{Name: "relocation-dns-master"}}
-	op, err := controllerutil.CreateOrUpdate(ctx, c, machineConfig, func() error {
-		snoDNSContents := fmt.Sprintf("address=/apps.%s/%s\n"+
-			"address=/api-int.%s/%s\n"+
-			"address=/api.%s/%s\n",
-			relocation.Spec.Domain, internalIP, relocation.Spec.Domain, internalIP, relocation.Spec.Domain, internalIP)
-
-		machineConfig.Labels = map[string]string{"machineconfiguration.openshift.io/role": "master"}
-		configData := MachineConfigData{
-			Ignition: map[string]string{"version": "3.2.0"},
-			Storage: MachineConfigStorageData{
-				Files: []MachineConfigFilesData{
-					{
-						Contents: map[string]string{
-							"source": fmt.Sprintf("data:text/plain;charset=utf-8;base64,%s", base64.StdEncoding.EncodeToString([]byte(snoDNSContents))),
-						},
-						Mode:      0o644,
-						Overwrite: true,
-						Path:      "/etc/dnsmasq.d/relocation-domain.conf",
-						User: map[string]string{
-							"name": "root",
-						},
-					},
-				},
-			},
+	var roles []string
+	var files []MachineConfigFilesData
+	if len(nodes.Items) == 1 {
+		roles = []string{"master"}
+		files = []MachineConfigFilesData{newFile(snoDNSMasqPath, dnsmasqContents(relocation, apiIPs, ingressIPs))}
+	} else {
+		roles = []string{"master", "worker"}
+		files = []MachineConfigFilesData{
+			newFile(networkManagerConfPath, "[main]\ndns=dnsmasq\n"),
+			newFile(networkManagerDNSMasqPath, dnsmasqContents(relocation, apiIPs, ingressIPs)),
 		}
-		bytes, err := json.Marshal(configData)
+	}
+
+	machineConfigs := []*machineconfigurationv1.MachineConfig{}
+	for _, v := range roles {
+		machineConfig := &machineconfigurationv1.MachineConfig{ObjectMeta: metav1.ObjectMeta{Name: machineConfigPrefix + v}}
+		op, err := controllerutil.CreateOrUpdate(ctx, c, machineConfig, func() error {
+			machineConfig.Labels = map[string]string{"machineconfiguration.openshift.io/role": v}
+			configData := MachineConfigData{
+				Ignition: map[string]string{"version": "3.2.0"},
+				Storage:  MachineConfigStorageData{Files: files},
+			}
+			bytes, err := json.Marshal(configData)
+			if err != nil {
+				return err
+			}
+			machineConfig.Spec.Config.Raw = bytes
+			// Set the controller as the owner so that the MachineConfig is deleted along with the CR
+			return controllerutil.SetControllerReference(relocation, machineConfig, scheme)
+		})
 		if err != nil {
 			return err
 		}
-		machineConfig.Spec.Config.Raw = bytes
-		// Set the controller as the owner so that the MachineConfig is deleted along with the CR
-		return controllerutil.SetControllerReference(relocation, machineConfig, scheme)
-	})
-	if err != nil {
-		return err
+		if op != controllerutil.OperationResultNone {
+			logger.Info("Updated DNS settings", "MachineConfig", machineConfig.Name, "APIServerIPs", apiIPs, "IngressIPs", ingressIPs, "OperationResult", op)
+		}
+		machineConfigs = append(machineConfigs, machineConfig)
 	}
-	if op != controllerutil.OperationResultNone {
-		logger.Info("Updated DNS settings", "OperationResult", op)
+
+	return waitForMachineConfigPools(ctx, c, relocation, machineConfigs, logger)
+}
+
+// returns true if the internal DNS entries are added to the nodes: always on SNO, and only when the dnsmasq plugin of NetworkManager is enabled on multi-node clusters
+func managesNodeDNS(relocation *rhsysenggithubiov1.ClusterRelocation, nodeCount int) bool {
+	if relocation.Spec.AddInternalDNSEntries == nil || !*relocation.Spec.AddInternalDNSEntries {
+		return false
+	}
+	return nodeCount == 1 || relocation.Spec.DNS.GetNetworkManagerDNSMasq()
+}
+
+// waits for every MachineConfigPool which selects one of the machineConfigs to include it, and to finish updating.
+// Custom pools usually select the worker MachineConfigs as well, so they are waited for too
+func waitForMachineConfigPools(ctx context.Context, c client.Client, relocation *rhsysenggithubiov1.ClusterRelocation, machineConfigs []*machineconfigurationv1.MachineConfig, logger logr.Logger) error {
+	pools := &machineconfigurationv1.MachineConfigPoolList{}
+	if err := c.List(ctx, pools); err != nil {
+		return err
 	}
 
-	// wait for the MachineConfigPool to include our new MachineConfig, and wait for it to update
-	logger.Info("waiting for MachineConfigPool to update")
-	masterMCP := &machineconfigurationv1.MachineConfigPool{}
-	for {
-		if err := c.Get(ctx, types.NamespacedName{Name: "master"}, masterMCP); err != nil {
+	waiting := []string{}
+	for _, pool := range pools.Items {
+		if pool.Spec.MachineConfigSelector == nil {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(pool.Spec.MachineConfigSelector)
+		if err != nil {
 			return err
 		}
-		found := false
-		for _, v := range masterMCP.Status.Configuration.Source {
-			if v.Name == "relocation-dns-master" {
-				found = true
+		for _, machineConfig := range machineConfigs {
+			if !selector.Matches(labels.Set(machineConfig.Labels)) {
+				continue
 			}
-		}
-		if !found {
-			time.Sleep(time.Second * 10)
-		} else {
-			if machineconfigurationv1.IsMachineConfigPoolConditionPresentAndEqual(masterMCP.Status.Conditions, machineconfigurationv1.MachineConfigPoolUpdating, corev1.ConditionFalse) {
+			found := false
+			for _, v := range pool.Status.Configuration.Source {
+				if v.Name == machineConfig.Name {
+					found = true
+				}
+			}
+			if !found || !machineconfigurationv1.IsMachineConfigPoolConditionPresentAndEqual(pool.Status.Conditions, machineconfigurationv1.MachineConfigPoolUpdating, corev1.ConditionFalse) {
+				waiting = append(waiting, pool.Name)
 				break
-			} else {
-				time.Sleep(time.Second * 10)
 			}
 		}
 	}
-
+	if len(waiting) > 0 {
+		sort.Strings(waiting)
+		logger.Info("waiting for MachineConfigPools to update", "MachineConfigPools", waiting)
+		return step.Wait("MachineConfigPools %s to apply the DNS MachineConfigs", strings.Join(waiting, ", ")).WithTimeout(relocation.Spec.Timeouts.GetMachineConfigPoolUpdate())
+	}
 	return nil
 }
 
-func getInternalIP(node corev1.Node) (string, error) {
+// returns the dnsmasq configuration which resolves api, api-int and *.apps of the new domain,
+// along with the additional records and forwarders of the spec
+func dnsmasqContents(relocation *rhsysenggithubiov1.ClusterRelocation, apiIPs []string, ingressIPs []string) string {
+	domain := relocation.Spec.Domain
+	var contents strings.Builder
+	for _, v := range ingressIPs {
+		fmt.Fprintf(&contents, "address=/apps.%s/%s\n", domain, v)
+	}
+	for _, v := range apiIPs {
+		fmt.Fprintf(&contents, "address=/api-int.%s/%s\n", domain, v)
+		fmt.Fprintf(&contents, "address=/api.%s/%s\n", domain, v)
+	}
+	if relocation.Spec.DNS == nil {
+		return contents.String()
+	}
+
+	for _, v := range relocation.Spec.DNS.Records {
+		if name, ok := strings.CutPrefix(v.Name, "*."); ok {
+			// address= also matches every name under the domain
+			for _, w := range v.Addresses {
+				fmt.Fprintf(&contents, "address=/%s/%s\n", name, w)
+			}
+		} else {
+			fmt.Fprintf(&contents, "host-record=%s,%s\n", v.Name, strings.Join(v.Addresses, ","))
+		}
+	}
+	for _, v := range relocation.Spec.DNS.Forwarders {
+		for _, w := range v.Upstreams {
+			fmt.Fprintf(&contents, "server=/%s/%s\n", v.Zone, dnsmasqServer(w))
+		}
+	}
+	return contents.String()
+}
+
+// converts an upstream server given as <IP> or <IP>:<port> to the format of dnsmasq (<IP>#<port>)
+func dnsmasqServer(upstream string) string {
+	if host, port, err := net.SplitHostPort(upstream); err == nil {
+		return fmt.Sprintf("%s#%s", host, port)
+	}
+	return upstream
+}
+
+func newFile(path string, contents string) MachineConfigFilesData {
+	return MachineConfigFilesData{
+		Contents: map[string]string{
+			"source": fmt.Sprintf("data:text/plain;charset=utf-8;base64,%s", base64.StdEncoding.EncodeToString([]byte(contents))),
+		},
+		Mode:      0o644,
+		Overwrite: true,
+		Path:      path,
+		User: map[string]string{
+			"name": "root",
+		},
+	}
+}
+
+// returns the addresses that the API and the ingress of the new domain resolve to.
+// They are taken from the spec if specified, otherwise from the node on SNO, or from the VIPs on multi-node clusters
+func getAddresses(ctx context.Context, c client.Client, relocation *rhsysenggithubiov1.ClusterRelocation, nodes []corev1.Node) ([]string, []string, error) {
+	if relocation.Spec.DNS != nil && relocation.Spec.DNS.Addresses != nil {
+		return relocation.Spec.DNS.Addresses.API, relocation.Spec.DNS.Addresses.Ingress, nil
+	}
+	if len(nodes) == 1 {
+		// on SNO, the API and the ingress are both served by the node itself
+		internalIPs, err := getInternalIPs(nodes[0])
+		if err != nil {
+			return nil, nil, err
+		}
+		return internalIPs, internalIPs, nil
+	}
+	return getVIPs(ctx, c)
+}
+
+// returns the API and ingress VIPs of the cluster, from the status of the Infrastructure.
+// Only the on-premise platforms have VIPs, on the other platforms the load balancers need external DNS records
+func getVIPs(ctx context.Context, c client.Client) ([]string, []string, error) {
+	infrastructure := &configv1.Infrastructure{}
+	if err := c.Get(ctx, types.NamespacedName{Name: "cluster"}, infrastructure); err != nil {
+		return nil, nil, err
+	}
+
+	var apiIPs, ingressIPs []string
+	var platform configv1.PlatformType
+	if platformStatus := infrastructure.Status.PlatformStatus; platformStatus != nil {
+		platform = platformStatus.Type
+		switch {
+		case platformStatus.BareMetal != nil:
+			apiIPs, ingressIPs = platformStatus.BareMetal.APIServerInternalIPs, platformStatus.BareMetal.IngressIPs
+		case platformStatus.VSphere != nil:
+			apiIPs, ingressIPs = platformStatus.VSphere.APIServerInternalIPs, platformStatus.VSphere.IngressIPs
+		case platformStatus.OpenStack != nil:
+			apiIPs, ingressIPs = platformStatus.OpenStack.APIServerInternalIPs, platformStatus.OpenStack.IngressIPs
+		case platformStatus.Ovirt != nil:
+			apiIPs, ingressIPs = platformStatus.Ovirt.APIServerInternalIPs, platformStatus.Ovirt.IngressIPs
+		case platformStatus.Nutanix != nil:
+			apiIPs, ingressIPs = platformStatus.Nutanix.APIServerInternalIPs, platformStatus.Nutanix.IngressIPs
+		}
+	}
+	if len(apiIPs) == 0 || len(ingressIPs) == 0 {
+		return nil, nil, fmt.Errorf("%w (platform %q)", errNoVIPs, platform)
+	}
+	return apiIPs, ingressIPs, nil
+}
+
+// returns the first internal IP address of the node for each IP family, so that dual-stack and IPv6 clusters get an entry for every family.
+// The addresses are returned in the order reported by the node, which puts the primary IP family first
+func getInternalIPs(node corev1.Node) ([]string, error) {
+	internalIPs := []string{}
+	families := map[bool]bool{}
 	for _, v := range node.Status.Addresses {
-		if v.Type == corev1.NodeInternalIP {
-			return v.Address, nil
+		if v.Type != corev1.NodeInternalIP {
+			continue
+		}
+		ip := net.ParseIP(v.Address)
+		if ip == nil {
+			continue
 		}
+		isIPv4 := ip.To4() != nil
+		if families[isIPv4] {
+			continue
+		}
+		families[isIPv4] = true
+		internalIPs = append(internalIPs, ip.String())
+	}
+	if len(internalIPs) == 0 {
+		return nil, fmt.Errorf("could not find node IP address")
 	}
-	return "", fmt.Errorf("could not find node IP address")
+	return internalIPs, nil
 }
@@ -0,0 +1,55 @@
+package dns
+
+import (
+	"context"
+
+	rhsysenggithubiov1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/step"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func init() {
+	step.Register(step.OrderDNS, dnsStep{})
+}
+
+type dnsStep struct{}
+
+func (dnsStep) Name() string { return rhsysenggithubiov1.StepDNS }
+
+func (dnsStep) ConditionType() string { return rhsysenggithubiov1.ConditionTypeDNSReady }
+
+func (dnsStep) FailureReason() string { return rhsysenggithubiov1.DNSReconciliationFailedReason }
+
+// The new domain always needs to resolve, even when the internal DNS entries are not managed by us
+func (dnsStep) Enabled(relocation *rhsysenggithubiov1.ClusterRelocation) bool {
+	return true
+}
+
+func (dnsStep) Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
+	if relocation.Spec.DNS != nil && relocation.Spec.DNS.ClusterDNS != nil {
+		// Adds forwarding zones to the cluster DNS
+		if err := ReconcileClusterDNS(ctx, c, scheme, relocation, logger); err != nil {
+			return err
+		}
+	} else if err := CleanupClusterDNS(ctx, c, logger); err != nil {
+		return err
+	}
+
+	if relocation.Spec.AddInternalDNSEntries != nil && *relocation.Spec.AddInternalDNSEntries {
+		// Adds new internal DNS records
+		if err := Reconcile(ctx, c, scheme, relocation, logger); err != nil {
+			return err
+		}
+	}
+
+	// Make sure DNS entries work
+	return Preflight(ctx, c, relocation, logger)
+}
+
+// The DNS MachineConfigs are owned by the CR, so they are deleted along with it.
+// The cluster DNS isn't owned by the CR, so its original servers are restored
+func (dnsStep) Cleanup(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
+	return CleanupClusterDNS(ctx, c, logger)
+}
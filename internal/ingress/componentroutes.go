@@ -0,0 +1,168 @@
+package ingress
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	rhsysenggithubiov1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
+	secrets "github.com/RHsyseng/cluster-relocation-operator/internal/secrets"
+	"github.com/go-logr/logr"
+	configv1 "github.com/openshift/api/config/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// the prefix of the copies of the component route certificates, in the openshift-config namespace
+const componentRouteSecretPrefix = "copied-route-"
+
+func componentRouteSecretName(namespace string, name string) string {
+	return fmt.Sprintf("%s%s-%s-secret", componentRouteSecretPrefix, namespace, name)
+}
+
+// returns the index of the component route with this namespace and name, or -1 if it isn't in routes
+func indexOfComponentRoute(routes []configv1.ComponentRouteSpec, namespace string, name string) int {
+	for i, v := range routes {
+		if v.Namespace == namespace && v.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// returns the component routes that we apply to the cluster Ingress: the console, downloads and oauth-openshift routes in the new domain,
+// served with the ingress certificate (secretName), along with the ComponentRoutes of the spec
+func componentRoutes(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger, secretName string) ([]configv1.ComponentRouteSpec, error) {
+	domain := relocation.Spec.Domain
+	routes := []configv1.ComponentRouteSpec{
+		{
+			Hostname:  configv1.Hostname(fmt.Sprintf("console-openshift-console.apps.%s", domain)),
+			Name:      "console",
+			Namespace: "openshift-console",
+			ServingCertKeyPairSecret: configv1.SecretNameReference{
+				Name: secretName,
+			},
+		},
+		{
+			Hostname:  configv1.Hostname(fmt.Sprintf("downloads-openshift-console.apps.%s", domain)),
+			Name:      "downloads",
+			Namespace: "openshift-console",
+			ServingCertKeyPairSecret: configv1.SecretNameReference{
+				Name: secretName,
+			},
+		},
+		{
+			Hostname:  configv1.Hostname(fmt.Sprintf("oauth-openshift.apps.%s", domain)),
+			Name:      "oauth-openshift",
+			Namespace: "openshift-authentication",
+			ServingCertKeyPairSecret: configv1.SecretNameReference{
+				Name: secretName,
+			},
+		},
+	}
+
+	for _, v := range relocation.Spec.ComponentRoutes {
+		i := indexOfComponentRoute(routes, v.Namespace, v.Name)
+		hostname := v.Hostname
+		if hostname == "" {
+			if i >= 0 {
+				hostname = string(routes[i].Hostname)
+			} else {
+				hostname = fmt.Sprintf("%s-%s.apps.%s", v.Name, v.Namespace, domain)
+			}
+		}
+
+		routeSecretName := secretName
+		if v.CertRef != nil {
+			// the certificate is checked before it is applied, since the route would not be able to serve it
+			if err := secrets.ValidateTLSSecret(ctx, c, v.CertRef, hostname); err != nil {
+				return nil, err
+			}
+			// The certificates of the component routes must be in the openshift-config namespace, so we copy it
+			routeSecretName = componentRouteSecretName(v.Namespace, v.Name)
+			copySettings := secrets.SecretCopySettings{
+				OwnOriginal:                  true,
+				OriginalOwnedByController:    false,
+				OwnDestination:               true,
+				DestinationOwnedByController: true,
+			}
+			op, err := secrets.CopySecret(ctx, c, relocation, scheme, v.CertRef.Name, v.CertRef.Namespace, routeSecretName, rhsysenggithubiov1.ConfigNamespace, copySettings)
+			if err != nil {
+				return nil, err
+			}
+			if op != controllerutil.OperationResultNone {
+				logger.Info(fmt.Sprintf("Component route cert copied to %s", rhsysenggithubiov1.ConfigNamespace), "route", v.Name, "namespace", v.Namespace, "OperationResult", op)
+			}
+		} else {
+			// the ingress certificate is only valid for *.apps.<domain> and the ingress additional names
+			if err := secrets.ValidateHostname(ctx, c, &corev1.SecretReference{Name: secretName, Namespace: rhsysenggithubiov1.ConfigNamespace}, hostname); err != nil {
+				return nil, err
+			}
+		}
+
+		route := configv1.ComponentRouteSpec{
+			Hostname:  configv1.Hostname(hostname),
+			Name:      v.Name,
+			Namespace: v.Namespace,
+			ServingCertKeyPairSecret: configv1.SecretNameReference{
+				Name: routeSecretName,
+			},
+		}
+		if i >= 0 {
+			routes[i] = route
+		} else {
+			routes = append(routes, route)
+		}
+	}
+	return routes, nil
+}
+
+// mergeComponentRoutes returns the component routes in current, with ours instead of the ones that we applied previously.
+// A route that we applied but no longer apply is restored from original, or removed if it wasn't in original. The routes of the cluster owner are kept as is
+func mergeComponentRoutes(current []configv1.ComponentRouteSpec, ours []configv1.ComponentRouteSpec, applied []configv1.ComponentRouteSpec, original []configv1.ComponentRouteSpec) []configv1.ComponentRouteSpec {
+	merged := append([]configv1.ComponentRouteSpec{}, ours...)
+	for _, v := range current {
+		if indexOfComponentRoute(ours, v.Namespace, v.Name) >= 0 {
+			continue
+		}
+		if indexOfComponentRoute(applied, v.Namespace, v.Name) >= 0 {
+			if i := indexOfComponentRoute(original, v.Namespace, v.Name); i >= 0 {
+				merged = append(merged, original[i])
+			}
+			continue
+		}
+		merged = append(merged, v)
+	}
+	if len(merged) == 0 {
+		return nil
+	}
+	return merged
+}
+
+// deletes the copies of the component route certificates which were served by the routes in applied, but aren't served by the routes in ours
+func deleteStaleComponentRouteSecrets(ctx context.Context, c client.Client, ours []configv1.ComponentRouteSpec, applied []configv1.ComponentRouteSpec) error {
+	for _, v := range applied {
+		secretName := v.ServingCertKeyPairSecret.Name
+		if !strings.HasPrefix(secretName, componentRouteSecretPrefix) {
+			continue
+		}
+		used := false
+		for _, w := range ours {
+			if w.ServingCertKeyPairSecret.Name == secretName {
+				used = true
+				break
+			}
+		}
+		if used {
+			continue
+		}
+		secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: rhsysenggithubiov1.ConfigNamespace}}
+		if err := c.Delete(ctx, secret); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package ingress
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+func newComponentRoute(namespace string, name string, hostname string, secretName string) configv1.ComponentRouteSpec {
+	return configv1.ComponentRouteSpec{
+		Namespace:                namespace,
+		Name:                     name,
+		Hostname:                 configv1.Hostname(hostname),
+		ServingCertKeyPairSecret: configv1.SecretNameReference{Name: secretName},
+	}
+}
+
+func TestMergeComponentRoutes(t *testing.T) {
+	ownerConsole := newComponentRoute("openshift-console", "console", "console.example.org", "owner-console")
+	ownerCustom := newComponentRoute("custom-ns", "custom", "custom.example.org", "owner-custom")
+	oldConsole := newComponentRoute("openshift-console", "console", "console-openshift-console.apps.old.example.com", "generated-ingress-old")
+	oldOAuth := newComponentRoute("openshift-authentication", "oauth-openshift", "oauth-openshift.apps.old.example.com", "generated-ingress-old")
+	newConsole := newComponentRoute("openshift-console", "console", "console-openshift-console.apps.new.example.com", "generated-ingress-new")
+	newDownloads := newComponentRoute("openshift-console", "downloads", "downloads-openshift-console.apps.new.example.com", "generated-ingress-new")
+
+	tests := []struct {
+		name     string
+		current  []configv1.ComponentRouteSpec
+		ours     []configv1.ComponentRouteSpec
+		applied  []configv1.ComponentRouteSpec
+		original []configv1.ComponentRouteSpec
+		want     []configv1.ComponentRouteSpec
+	}{
+		{
+			name: "nothing to merge",
+			want: nil,
+		},
+		{
+			name:    "first apply keeps the routes of the cluster owner",
+			current: []configv1.ComponentRouteSpec{ownerCustom},
+			ours:    []configv1.ComponentRouteSpec{newConsole},
+			want:    []configv1.ComponentRouteSpec{newConsole, ownerCustom},
+		},
+		{
+			name:     "our route replaces the route of the cluster owner",
+			current:  []configv1.ComponentRouteSpec{ownerConsole, ownerCustom},
+			ours:     []configv1.ComponentRouteSpec{newConsole},
+			original: []configv1.ComponentRouteSpec{ownerConsole, ownerCustom},
+			want:     []configv1.ComponentRouteSpec{newConsole, ownerCustom},
+		},
+		{
+			name:     "routes that we applied previously are replaced by ours",
+			current:  []configv1.ComponentRouteSpec{oldConsole, ownerCustom},
+			ours:     []configv1.ComponentRouteSpec{newConsole},
+			applied:  []configv1.ComponentRouteSpec{oldConsole},
+			original: []configv1.ComponentRouteSpec{ownerCustom},
+			want:     []configv1.ComponentRouteSpec{newConsole, ownerCustom},
+		},
+		{
+			name:     "route that we no longer apply is restored from original",
+			current:  []configv1.ComponentRouteSpec{oldConsole, newDownloads, ownerCustom},
+			ours:     []configv1.ComponentRouteSpec{newDownloads},
+			applied:  []configv1.ComponentRouteSpec{oldConsole, newDownloads},
+			original: []configv1.ComponentRouteSpec{ownerConsole, ownerCustom},
+			want:     []configv1.ComponentRouteSpec{newDownloads, ownerConsole, ownerCustom},
+		},
+		{
+			name:     "route that we no longer apply is removed if it wasn't in original",
+			current:  []configv1.ComponentRouteSpec{oldOAuth, newDownloads},
+			ours:     []configv1.ComponentRouteSpec{newDownloads},
+			applied:  []configv1.ComponentRouteSpec{oldOAuth, newDownloads},
+			original: []configv1.ComponentRouteSpec{ownerCustom},
+			want:     []configv1.ComponentRouteSpec{newDownloads},
+		},
+		{
+			name:     "route added by the cluster owner after the relocation is kept",
+			current:  []configv1.ComponentRouteSpec{newConsole, ownerCustom},
+			ours:     []configv1.ComponentRouteSpec{newConsole},
+			applied:  []configv1.ComponentRouteSpec{newConsole},
+			original: nil,
+			want:     []configv1.ComponentRouteSpec{newConsole, ownerCustom},
+		},
+		{
+			name:     "cleanup restores the original routes",
+			current:  []configv1.ComponentRouteSpec{newConsole, newDownloads, ownerCustom},
+			applied:  []configv1.ComponentRouteSpec{newConsole, newDownloads},
+			original: []configv1.ComponentRouteSpec{ownerConsole, ownerCustom},
+			want:     []configv1.ComponentRouteSpec{ownerConsole, ownerCustom},
+		},
+		{
+			name:    "cleanup without original routes removes ours",
+			current: []configv1.ComponentRouteSpec{newConsole, newDownloads},
+			applied: []configv1.ComponentRouteSpec{newConsole, newDownloads},
+			want:    nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mergeComponentRoutes(tt.current, tt.ours, tt.applied, tt.original)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unexpected component routes (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package ingress
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	rhsysenggithubiov1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/backup"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/certmanager"
+	secrets "github.com/RHsyseng/cluster-relocation-operator/internal/secrets"
+	"github.com/go-logr/logr"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// the prefix of the backup keys which hold the original default certificate of an IngressController
+const ingressControllerKeyPrefix = "ingresscontroller."
+
+// returns the name of the certificate of an additional IngressController, in the status and in the metrics
+func certificateName(name string) string {
+	return fmt.Sprintf("%s-%s", rhsysenggithubiov1.CertificateIngress, name)
+}
+
+func generatedSecretName(name string) string {
+	return fmt.Sprintf("generated-ingress-%s-secret", name)
+}
+
+func copiedSecretName(name string) string {
+	return fmt.Sprintf("copied-ingress-%s-secret", name)
+}
+
+// ReconcileIngressControllers applies a certificate for *.<domain> to each of the additional IngressControllers,
+// and reverts the IngressControllers which are no longer listed in the spec
+func ReconcileIngressControllers(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
+	for _, v := range relocation.Spec.IngressControllers {
+		name := certificateName(v.Name)
+		secretName, err := reconcileCertificate(ctx, c, scheme, relocation, logger, name, v.CertRef, v.Domain, "*", nil, generatedSecretName(v.Name), copiedSecretName(v.Name))
+		if err != nil {
+			return err
+		}
+		if err := setDefaultCertificate(ctx, c, scheme, relocation, logger, v.Name, secretName); err != nil {
+			return err
+		}
+		if v.CertRef != nil || relocation.Spec.CertManager == nil {
+			// the certificate is no longer requested from cert-manager
+			if err := certmanager.Cleanup(ctx, c, name, nil); err != nil {
+				return err
+			}
+		}
+	}
+	return CleanupIngressControllers(ctx, c, relocation, logger, relocation.Spec.IngressControllers)
+}
+
+// CleanupIngressControllers restores the original default certificate of the additional IngressControllers that we modified, except for the ones in keep,
+// and deletes their certificates
+func CleanupIngressControllers(ctx context.Context, c client.Client, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger, keep []rhsysenggithubiov1.IngressController) error {
+	keys, err := backup.List(ctx, c, ingressControllerKeyPrefix)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		name := strings.TrimSuffix(strings.TrimPrefix(key, ingressControllerKeyPrefix), ".defaultCertificate")
+		if key != backup.IngressControllerDefaultCertificateKeyFor(name) || name == rhsysenggithubiov1.DefaultIngressController {
+			continue
+		}
+		kept := false
+		for _, v := range keep {
+			if v.Name == name {
+				kept = true
+				break
+			}
+		}
+		if kept {
+			continue
+		}
+
+		if err := restoreDefaultCertificate(ctx, c, logger, name); err != nil {
+			return err
+		}
+		secrets.ForgetCertificate(relocation, certificateName(name))
+		if err := certmanager.Cleanup(ctx, c, certificateName(name), nil); err != nil {
+			return err
+		}
+		for _, secretName := range []string{generatedSecretName(name), copiedSecretName(name)} {
+			secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: rhsysenggithubiov1.IngressNamespace}}
+			if err := c.Delete(ctx, secret); client.IgnoreNotFound(err) != nil {
+				return err
+			}
+		}
+		logger.Info("IngressController is no longer relocated", "IngressController", name)
+	}
+	return nil
+}
+
+// ResetIngressControllerRoutes re-creates the Routes of each of the additional IngressControllers with its new domain
+func ResetIngressControllerRoutes(ctx context.Context, c client.Client, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
+	for _, v := range relocation.Spec.IngressControllers {
+		var selector labels.Selector
+		if v.RouteSelector != nil {
+			var err error
+			selector, err = metav1.LabelSelectorAsSelector(v.RouteSelector)
+			if err != nil {
+				return err
+			}
+		}
+		if err := ResetRoutes(ctx, c, relocation, logger, v.Name, v.Domain, nil, selector); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OriginalDomain returns the domain of the named IngressController, which its Routes are re-created with once its default certificate is restored
+func OriginalDomain(ctx context.Context, c client.Client, name string) (string, error) {
+	ingressController := &operatorv1.IngressController{}
+	if err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: rhsysenggithubiov1.IngressOperatorNamespace}, ingressController); err != nil {
+		return "", err
+	}
+	if ingressController.Status.Domain != "" {
+		return ingressController.Status.Domain, nil
+	}
+	return ingressController.Spec.Domain, nil
+}
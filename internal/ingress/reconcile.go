@@ -5,249 +5,346 @@ import (
 	"fmt"
 	"strings"
 
-	rhsysenggithubiov1beta1 "github.com/RHsyseng/cluster-relocation-operator/api/v1beta1"
+	rhsysenggithubiov1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/backup"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/certmanager"
 	secrets "github.com/RHsyseng/cluster-relocation-operator/internal/secrets"
-	"github.com/RHsyseng/cluster-relocation-operator/internal/util"
 	"github.com/go-logr/logr"
 	configv1 "github.com/openshift/api/config/v1"
 	operatorv1 "github.com/openshift/api/operator/v1"
-	routev1 "github.com/openshift/api/route/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
-//+kubebuilder:rbac:groups="",resources=secrets,verbs=create;update;get;list;watch
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=create;update;delete;get;list;watch
 //+kubebuilder:rbac:groups=operator.openshift.io,resources=ingresscontrollers,verbs=patch;get;list;watch
 //+kubebuilder:rbac:groups=config.openshift.io,resources=ingresses,verbs=patch;get;list;watch
-//+kubebuilder:rbac:groups=route.openshift.io,resources=routes,verbs=list;delete;watch
 
-func Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1beta1.ClusterRelocation, logger logr.Logger) error {
+func Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
 	// Configure certificates with the new domain name for the ingress
-	var origSecretName string
-	var origSecretNamespace string
-	if relocation.Spec.IngressCertRef == nil {
-		// If they haven't specified an IngressCertRef, we generate a certificate for them
-		origSecretName = "generated-ingress-secret"
-		origSecretNamespace = rhsysenggithubiov1beta1.IngressNamespace
-		secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: origSecretName, Namespace: origSecretNamespace}}
+	origSecretName, err := reconcileCertificate(ctx, c, scheme, relocation, logger, rhsysenggithubiov1.CertificateIngress, relocation.Spec.IngressCertRef,
+		relocation.Spec.Domain, "*.apps", relocation.Spec.CertificateGeneration.GetIngressAdditionalNames(), "generated-ingress-secret", "copied-ingress-secret")
+	if err != nil {
+		return err
+	}
+
+	// The certificate must be in the openshift-config namespace as well, for the component routes
+	copySettings := secrets.SecretCopySettings{
+		OwnOriginal:                  false,
+		OriginalOwnedByController:    false,
+		OwnDestination:               true,
+		DestinationOwnedByController: true,
+	}
+	op, err := secrets.CopySecret(ctx, c, relocation, scheme, origSecretName, rhsysenggithubiov1.IngressNamespace, origSecretName, rhsysenggithubiov1.ConfigNamespace, copySettings)
+	if err != nil {
+		return err
+	}
+	if op != controllerutil.OperationResultNone {
+		logger.Info(fmt.Sprintf("Ingress cert copied to %s", rhsysenggithubiov1.ConfigNamespace), "OperationResult", op)
+	}
 
+	if err := setDefaultCertificate(ctx, c, scheme, relocation, logger, rhsysenggithubiov1.DefaultIngressController, origSecretName); err != nil {
+		return err
+	}
+
+	ours, err := componentRoutes(ctx, c, scheme, relocation, logger, origSecretName)
+	if err != nil {
+		return err
+	}
+	original := []configv1.ComponentRouteSpec{}
+	if _, err := backup.Restore(ctx, c, backup.IngressComponentRoutesKey, &original); err != nil {
+		return err
+	}
+	applied := []configv1.ComponentRouteSpec{}
+	if _, err := backup.Restore(ctx, c, backup.AppliedIngressComponentRoutesKey, &applied); err != nil {
+		return err
+	}
+
+	ingress := &configv1.Ingress{}
+	if err := c.Get(ctx, types.NamespacedName{Name: "cluster"}, ingress); err != nil {
+		return err
+	}
+	// save the original domain alias and component routes, so that they can be restored when the CR is deleted.
+	// The values applied by a previous version of the operator, which didn't save the original ones, are not saved
+	appsDomain := fmt.Sprintf("apps.%s", relocation.Spec.Domain)
+	if err := backup.SaveOriginal(ctx, c, scheme, relocation, logger, backup.IngressAppsDomainKey, ingress.Spec.AppsDomain, "", ingress.Spec.AppsDomain == appsDomain); err != nil {
+		return err
+	}
+	knownComponentRoutes := []configv1.ComponentRouteSpec{}
+	for _, v := range ingress.Spec.ComponentRoutes {
+		if !isOperatorSecret(v.ServingCertKeyPairSecret.Name) && !strings.HasSuffix(string(v.Hostname), "."+appsDomain) {
+			knownComponentRoutes = append(knownComponentRoutes, v)
+		}
+	}
+	if len(knownComponentRoutes) == 0 {
+		knownComponentRoutes = nil
+	}
+	if err := backup.SaveOriginal(ctx, c, scheme, relocation, logger, backup.IngressComponentRoutesKey, ingress.Spec.ComponentRoutes, knownComponentRoutes,
+		len(knownComponentRoutes) != len(ingress.Spec.ComponentRoutes)); err != nil {
+		return err
+	}
+
+	op, err = controllerutil.CreateOrPatch(ctx, c, ingress, func() error {
+		ingress.Spec.AppsDomain = appsDomain
+		// the component routes are shared with the cluster owner, so we only replace the routes that we applied
+		ingress.Spec.ComponentRoutes = mergeComponentRoutes(ingress.Spec.ComponentRoutes, ours, applied, original)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := backup.SaveApplied(ctx, c, scheme, relocation, backup.AppliedIngressComponentRoutesKey, ours); err != nil {
+		return err
+	}
+	if err := deleteStaleComponentRouteSecrets(ctx, c, ours, applied); err != nil {
+		return err
+	}
+
+	if op != controllerutil.OperationResultNone {
+		logger.Info("Ingress domain aliases modified", "OperationResult", op)
+	}
+
+	if relocation.Spec.IngressCertRef != nil || relocation.Spec.CertManager == nil {
+		// the certificate is no longer requested from cert-manager, and the copies of the previous one have been replaced
+		return certmanager.Cleanup(ctx, c, rhsysenggithubiov1.CertificateIngress, nil)
+	}
+	return nil
+}
+
+// reconciles the certificate of an IngressController, and returns the name of its secret in the openshift-ingress namespace.
+// If certRef is nil, a certificate for <prefix>.<domain> is requested from cert-manager when CertManager is set, otherwise it is generated as generatedSecretName.
+// If certRef is set, or once cert-manager has issued the certificate, the secret is validated and copied as copiedSecretName
+func reconcileCertificate(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger,
+	certificateName string, certRef *corev1.SecretReference, domain string, prefix string, additionalNames []string, generatedSecretName string, copiedSecretName string) (string, error) {
+	userProvided := certRef != nil
+	if certRef == nil && relocation.Spec.CertManager != nil {
+		// the certificate is requested from cert-manager, then used in the same way as a user provided certificate
+		var err error
+		certRef, err = certmanager.Reconcile(ctx, c, scheme, relocation, logger, certificateName, fmt.Sprintf("%s.%s", prefix, domain), additionalNames)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if certRef == nil {
+		// If they haven't specified a certificate, we generate a certificate for them
+		secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: generatedSecretName, Namespace: rhsysenggithubiov1.IngressNamespace}}
+
+		generation := relocation.Spec.CertificateGeneration
+		signer, err := secrets.GetSigner(ctx, c, relocation)
+		if err != nil {
+			return "", err
+		}
+		request := secrets.NewCertificateRequest(domain, prefix, additionalNames, generation, signer)
+		hash, err := request.Hash()
+		if err != nil {
+			return "", err
+		}
 		op, err := controllerutil.CreateOrUpdate(ctx, c, secret, func() error {
+			// The hash of the request is stored in an annotation, so that we don't generate a new certificate each time Reconcile runs,
+			// but only when there is no certificate yet, when the domain or the generation settings have changed, or when it is about to expire
 			_, ok := secret.Data[corev1.TLSCertKey]
-			// Check if the secret already has a key set
-			// If there is no key set, generate one
-			// This is done so that we don't generate a new certificate each time Reconcile runs
-			if !ok {
-				logger.Info("generating new TLS cert for Ingresses")
-				var err error
-				secret.Data, err = secrets.GenerateTLSKeyPair(ctx, c, relocation.Spec.Domain, "*.apps")
+			generate := !ok || secret.Annotations[secrets.CertificateHashAnnotation] != hash
+			if !generate {
+				renew, err := secrets.NeedsRenewal(secret, generation.GetRenewBefore())
 				if err != nil {
-					return err
+					logger.Info(fmt.Sprintf("could not read the TLS cert for %s, generating a new one", certificateName), "error", err.Error())
+				} else if renew {
+					logger.Info(fmt.Sprintf("TLS cert for %s is about to expire, generating a new one", certificateName))
 				}
-			} else {
-				logger.Info("TLS cert already exists for Ingresses")
-				commonName, err := secrets.GetCertCommonName(secret.Data[corev1.TLSCertKey])
+				generate = err != nil || renew
+			}
+			if generate {
+				logger.Info(fmt.Sprintf("generating new TLS cert for %s", certificateName), "KeyAlgorithm", request.KeyAlgorithm, "KeySize", request.KeySize, "DNSNames", request.DNSNames, "IPAddresses", request.IPAddresses)
+				var err error
+				secret.Data, err = secrets.GenerateTLSKeyPair(signer, request)
 				if err != nil {
 					return err
 				}
-				if commonName != fmt.Sprintf("*.apps.%s", relocation.Spec.Domain) {
-					logger.Info("Domain name has changed, generating new TLS certificate for Ingresses")
-					var err error
-					secret.Data, err = secrets.GenerateTLSKeyPair(ctx, c, relocation.Spec.Domain, "*.apps")
-					if err != nil {
-						return err
-					}
-				}
+				metav1.SetMetaDataAnnotation(&secret.ObjectMeta, secrets.CertificateHashAnnotation, hash)
+			} else {
+				logger.Info(fmt.Sprintf("TLS cert already exists for %s", certificateName))
 			}
 			secret.Type = corev1.SecretTypeTLS
 			// Set the controller as the owner so that the secret is deleted along with the CR
 			return controllerutil.SetControllerReference(relocation, secret, scheme)
 		})
 		if err != nil {
-			return err
+			return "", err
 		}
 		if op != controllerutil.OperationResultNone {
-			logger.Info("Ingress TLS cert modified", "OperationResult", op)
+			logger.Info(fmt.Sprintf("%s TLS cert modified", certificateName), "OperationResult", op)
 		}
-
-		secretName := origSecretName
-		copySettings := secrets.SecretCopySettings{
-			OwnOriginal:                  false,
-			OriginalOwnedByController:    false,
-			OwnDestination:               true,
-			DestinationOwnedByController: true,
-		}
-		op, err = secrets.CopySecret(ctx, c, relocation, scheme, origSecretName, origSecretNamespace, secretName, rhsysenggithubiov1beta1.ConfigNamespace, copySettings)
-		if err != nil {
-			return err
-		}
-		if op != controllerutil.OperationResultNone {
-			logger.Info(fmt.Sprintf("Generated Ingress cert copied to %s", rhsysenggithubiov1beta1.ConfigNamespace), "OperationResult", op)
-		}
-	} else {
-		if relocation.Spec.IngressCertRef.Name == "" || relocation.Spec.IngressCertRef.Namespace == "" {
-			return fmt.Errorf("must specify secret name and namespace")
-		}
-		if err := secrets.ValidateSecretType(ctx, c, relocation.Spec.IngressCertRef, corev1.SecretTypeTLS); err != nil {
-			return err
+		if err := secrets.RecordCertificate(relocation, certificateName, secret, generation.GetRenewBefore()); err != nil {
+			return "", err
 		}
+		return generatedSecretName, nil
+	}
 
-		origSecretName = relocation.Spec.IngressCertRef.Name
-		origSecretNamespace = relocation.Spec.IngressCertRef.Namespace
-		logger.Info("Using user provided Ingress certificate", "namespace", origSecretNamespace, "name", origSecretName)
-
-		// The certificate must be in the openshift-ingress namespace
-		// as well as the openshift-config namespace, so we copy it
-		secretName := "copied-ingress-secret"
-		// Copy the secret into the openshift-ingress namespace
-		// the original may be owned by another controller (cert-manager for example)
-		// we add non-controller ownership to this secret, in order to watch it.
-		// our controller should own the destination secret
-		copySettings := secrets.SecretCopySettings{
-			OwnOriginal:                  true,
-			OriginalOwnedByController:    false,
-			OwnDestination:               true,
-			DestinationOwnedByController: true,
-		}
-		op, err := secrets.CopySecret(ctx, c, relocation, scheme, origSecretName, origSecretNamespace, secretName, rhsysenggithubiov1beta1.IngressNamespace, copySettings)
-		if err != nil {
-			return err
-		}
-		if op != controllerutil.OperationResultNone {
-			logger.Info(fmt.Sprintf("User provided Ingress cert copied to %s", rhsysenggithubiov1beta1.IngressNamespace), "OperationResult", op)
-		}
+	if certRef.Name == "" || certRef.Namespace == "" {
+		return "", fmt.Errorf("must specify secret name and namespace")
+	}
+	// the certificate is checked before it is applied, since the ingress would not be able to serve it
+	if err := secrets.ValidateTLSSecret(ctx, c, certRef, fmt.Sprintf("%s.%s", prefix, domain)); err != nil {
+		return "", err
+	}
+	if userProvided {
+		// the user provided certificate is not renewed by us
+		secrets.ForgetCertificate(relocation, certificateName)
+		logger.Info(fmt.Sprintf("Using user provided %s certificate", certificateName), "namespace", certRef.Namespace, "name", certRef.Name)
+	}
 
-		// Copy the secret into the openshift-config namespace
-		op, err = secrets.CopySecret(ctx, c, relocation, scheme, origSecretName, origSecretNamespace, secretName, rhsysenggithubiov1beta1.ConfigNamespace, copySettings)
-		if err != nil {
-			return err
-		}
-		if op != controllerutil.OperationResultNone {
-			logger.Info(fmt.Sprintf("User provided Ingress cert copied to %s", rhsysenggithubiov1beta1.ConfigNamespace), "OperationResult", op)
-		}
-		origSecretName = secretName
+	// The certificate must be in the openshift-ingress namespace, so we copy it
+	// the original may be owned by another controller (cert-manager for example)
+	// we add non-controller ownership to this secret, in order to watch it.
+	// our controller should own the destination secret
+	copySettings := secrets.SecretCopySettings{
+		OwnOriginal:                  true,
+		OriginalOwnedByController:    false,
+		OwnDestination:               true,
+		DestinationOwnedByController: true,
 	}
+	op, err := secrets.CopySecret(ctx, c, relocation, scheme, certRef.Name, certRef.Namespace, copiedSecretName, rhsysenggithubiov1.IngressNamespace, copySettings)
+	if err != nil {
+		return "", err
+	}
+	if op != controllerutil.OperationResultNone {
+		logger.Info(fmt.Sprintf("%s cert copied to %s", certificateName, rhsysenggithubiov1.IngressNamespace), "OperationResult", op)
+	}
+	return copiedSecretName, nil
+}
 
-	// Define the IngressController's namespace and name
-	namespace := "openshift-ingress-operator"
-	name := "default"
+// returns true if the secret is one of the ingress certificates created by the operator, which are deleted along with the CR
+func isOperatorSecret(name string) bool {
+	return strings.HasPrefix(name, "generated-ingress-") || strings.HasPrefix(name, "copied-ingress-") || strings.HasPrefix(name, componentRouteSecretPrefix)
+}
 
-	ingressController := &operatorv1.IngressController{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+// sets the default certificate of the named IngressController, after saving the original one
+func setDefaultCertificate(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger, name string, secretName string) error {
+	ingressController := &operatorv1.IngressController{}
+	if err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: rhsysenggithubiov1.IngressOperatorNamespace}, ingressController); err != nil {
+		// the IngressController is managed by the ingress operator, so we don't create it
+		return err
+	}
+	// save the original default certificate, so that it can be restored when the CR is deleted.
+	// If it is already one of our secrets, the original is unknown, so the default certificate of the ingress operator is restored instead
+	defaultCertificate := ingressController.Spec.DefaultCertificate
+	relocated := defaultCertificate != nil && isOperatorSecret(defaultCertificate.Name)
+	if err := backup.SaveOriginal(ctx, c, scheme, relocation, logger, backup.IngressControllerDefaultCertificateKeyFor(name), defaultCertificate, (*corev1.LocalObjectReference)(nil), relocated); err != nil {
+		return err
+	}
 	op, err := controllerutil.CreateOrPatch(ctx, c, ingressController, func() error {
-		ingressController.Spec.DefaultCertificate = &corev1.LocalObjectReference{Name: origSecretName}
+		ingressController.Spec.DefaultCertificate = &corev1.LocalObjectReference{Name: secretName}
 		return nil
 	})
 	if err != nil {
 		return err
 	}
-
 	if op != controllerutil.OperationResultNone {
-		logger.Info("IngressController modified", "OperationResult", op)
+		logger.Info("IngressController modified", "IngressController", name, "OperationResult", op)
 	}
+	return nil
+}
 
-	ingress := &configv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}}
-	op, err = controllerutil.CreateOrPatch(ctx, c, ingress, func() error {
-		ingress.Spec.AppsDomain = fmt.Sprintf("apps.%s", relocation.Spec.Domain)
-		ingress.Spec.ComponentRoutes = []configv1.ComponentRouteSpec{
-			{
-				Hostname:  configv1.Hostname(fmt.Sprintf("console-openshift-console.apps.%s", relocation.Spec.Domain)),
-				Name:      "console",
-				Namespace: "openshift-console",
-				ServingCertKeyPairSecret: configv1.SecretNameReference{
-					Name: origSecretName,
-				},
-			},
-			{
-				Hostname:  configv1.Hostname(fmt.Sprintf("downloads-openshift-console.apps.%s", relocation.Spec.Domain)),
-				Name:      "downloads",
-				Namespace: "openshift-console",
-				ServingCertKeyPairSecret: configv1.SecretNameReference{
-					Name: origSecretName,
-				},
-			},
-			{
-				Hostname:  configv1.Hostname(fmt.Sprintf("oauth-openshift.apps.%s", relocation.Spec.Domain)),
-				Name:      "oauth-openshift",
-				Namespace: "openshift-authentication",
-				ServingCertKeyPairSecret: configv1.SecretNameReference{
-					Name: origSecretName,
-				},
-			},
+// restores the original default certificate of the named IngressController, if we modified it
+func restoreDefaultCertificate(ctx context.Context, c client.Client, logger logr.Logger, name string) error {
+	key := backup.IngressControllerDefaultCertificateKeyFor(name)
+	var defaultCertificate *corev1.LocalObjectReference
+	found, err := backup.Restore(ctx, c, key, &defaultCertificate)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return nil
+	}
+	ingressController := &operatorv1.IngressController{}
+	if err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: rhsysenggithubiov1.IngressOperatorNamespace}, ingressController); err != nil {
+		if errors.IsNotFound(err) {
+			// the IngressController was deleted in the meantime, so there is nothing to restore
+			return backup.Remove(ctx, c, key)
 		}
 		return err
+	}
+	op, err := controllerutil.CreateOrPatch(ctx, c, ingressController, func() error {
+		ingressController.Spec.DefaultCertificate = defaultCertificate
+		return nil
 	})
 	if err != nil {
 		return err
 	}
-
 	if op != controllerutil.OperationResultNone {
-		logger.Info("Ingress domain aliases modified", "OperationResult", op)
+		logger.Info("Ingress Controller reverted to original state", "IngressController", name, "OperationResult", op)
 	}
-
-	return nil
+	return backup.Remove(ctx, c, key)
 }
 
 // We modified the Ingress Controller and Ingress Cluster resources, but we don't own it
 // Therefore, we need to use a finalizer to put it back the way we found it if the CR is deleted
 func Cleanup(ctx context.Context, c client.Client, logger logr.Logger) error {
-	namespace := "openshift-ingress-operator"
-	name := "default"
-	ingressController := &operatorv1.IngressController{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
-	op, err := controllerutil.CreateOrPatch(ctx, c, ingressController, func() error {
-		ingressController.Spec.DefaultCertificate = nil
-		return nil
-	})
+	if err := restoreDefaultCertificate(ctx, c, logger, rhsysenggithubiov1.DefaultIngressController); err != nil {
+		return err
+	}
+
+	var appsDomain string
+	appsDomainFound, err := backup.Restore(ctx, c, backup.IngressAppsDomainKey, &appsDomain)
 	if err != nil {
 		return err
 	}
-	if op != controllerutil.OperationResultNone {
-		logger.Info("Ingress Controller reverted to original state", "OperationResult", op)
+	componentRoutes := []configv1.ComponentRouteSpec{}
+	if _, err := backup.Restore(ctx, c, backup.IngressComponentRoutesKey, &componentRoutes); err != nil {
+		return err
 	}
-	ingress := &configv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}}
-	op, err = controllerutil.CreateOrPatch(ctx, c, ingress, func() error {
-		ingress.Spec.AppsDomain = ""
-		ingress.Spec.ComponentRoutes = nil
-		return nil
-	})
+	applied := []configv1.ComponentRouteSpec{}
+	appliedFound, err := backup.Restore(ctx, c, backup.AppliedIngressComponentRoutesKey, &applied)
 	if err != nil {
 		return err
 	}
-	if op != controllerutil.OperationResultNone {
-		logger.Info("Cluster Ingress reverted to original state", "OperationResult", op)
+	if appsDomainFound || appliedFound {
+		ingress := &configv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}}
+		op, err := controllerutil.CreateOrPatch(ctx, c, ingress, func() error {
+			if appsDomainFound {
+				ingress.Spec.AppsDomain = appsDomain
+			}
+			if appliedFound {
+				// only the routes that we applied are restored, the routes added by the cluster owner in the meantime are kept
+				ingress.Spec.ComponentRoutes = mergeComponentRoutes(ingress.Spec.ComponentRoutes, nil, applied, componentRoutes)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if op != controllerutil.OperationResultNone {
+			logger.Info("Cluster Ingress reverted to original state", "OperationResult", op)
+		}
+		if err := backup.Remove(ctx, c, backup.IngressAppsDomainKey); err != nil {
+			return err
+		}
+		if err := backup.Remove(ctx, c, backup.IngressComponentRoutesKey); err != nil {
+			return err
+		}
+		if err := backup.Remove(ctx, c, backup.AppliedIngressComponentRoutesKey); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-func ResetRoutes(ctx context.Context, c client.Client, domainName string, logger logr.Logger) error {
-	routes := &routev1.RouteList{}
-	if err := c.List(ctx, routes); err != nil {
-		return err
+// AppsDomain returns the domain that the Routes are served on.
+// This is the domain alias of the cluster Ingress if there is one, otherwise it is the original apps domain of the cluster.
+func AppsDomain(ctx context.Context, c client.Client) (string, error) {
+	ingress := &configv1.Ingress{}
+	if err := c.Get(ctx, types.NamespacedName{Name: "cluster"}, ingress); err != nil {
+		return "", err
 	}
-
-	if err := util.WaitForCO(ctx, c, logger, "openshift-apiserver"); err != nil {
-		return err
+	if ingress.Spec.AppsDomain != "" {
+		return ingress.Spec.AppsDomain, nil
 	}
-
-	for _, v := range routes.Items {
-		if v.Namespace == "openshift-console" || v.Namespace == "openshift-authentication" || v.Namespace == "open-cluster-management-agent-addon" {
-			// open-cluster-management-agent-addon is ignored because right now the Klusterlet Add-on ignores the "appsDomain" setting
-			// A PR has been opened to correct this: https://github.com/stolostron/multicloud-operators-foundation/pull/642
-			// without this fix, the Route created by the Klusterlet is always re-created with the original domain
-			continue
-		}
-		for _, w := range v.Status.Ingress {
-			if w.RouterName == "default" { // check Routes associated with the default Ingress Controller
-				if !strings.Contains(w.Host, domainName) { // hostname for this route needs to be updated
-					if err := c.Delete(ctx, &v); err != nil {
-						return err
-					}
-					logger.Info("Deleted Route so that it can be re-created with new domain", "Route", v.Name, "Host", w.Host, "namespace", v.Namespace)
-				}
-			}
-		}
-	}
-	return nil
+	return ingress.Spec.Domain, nil
 }
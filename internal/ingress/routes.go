@@ -0,0 +1,197 @@
+package ingress
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	rhsysenggithubiov1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/util"
+	"github.com/go-logr/logr"
+	routev1 "github.com/openshift/api/route/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//+kubebuilder:rbac:groups=route.openshift.io,resources=routes,verbs=get;list;watch;patch;delete
+//+kubebuilder:rbac:groups=route.openshift.io,resources=routes/custom-host,verbs=create;update
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+
+// set by the API server on the Routes whose spec.host was generated, rather than given by their owner
+const hostGeneratedAnnotation = "openshift.io/host.generated"
+
+// ResetRoutes moves the Routes admitted by the named IngressController to domainName, according to the RouteReset of the spec:
+// the Routes are either deleted so that they are re-created with the new domain, or their hostname is rewritten.
+// Only the Routes whose hostname is in the domain of the router, or in one of fromDomains, are reset. The Routes on other domains (e.g. a custom domain) are left as is.
+// If selector is not nil, only the Routes with matching labels are reset. Each Route that is reset is recorded in the status
+func ResetRoutes(ctx context.Context, c client.Client, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger, routerName string, domainName string, fromDomains []string, selector labels.Selector) error {
+	routeReset := relocation.Spec.RouteReset
+	if routeReset != nil && routeReset.RouteSelector != nil {
+		routeSelector, err := metav1.LabelSelectorAsSelector(routeReset.RouteSelector)
+		if err != nil {
+			return err
+		}
+		if selector != nil {
+			// the Routes must match both selectors
+			requirements, _ := selector.Requirements()
+			routeSelector = routeSelector.Add(requirements...)
+		}
+		selector = routeSelector
+	}
+	routes := &routev1.RouteList{}
+	opts := []client.ListOption{}
+	if selector != nil {
+		opts = append(opts, client.MatchingLabelsSelector{Selector: selector})
+	}
+	if err := c.List(ctx, routes, opts...); err != nil {
+		return err
+	}
+
+	var namespaces map[string]bool
+	if routeReset != nil && routeReset.NamespaceSelector != nil {
+		namespaceSelector, err := metav1.LabelSelectorAsSelector(routeReset.NamespaceSelector)
+		if err != nil {
+			return err
+		}
+		namespaceList := &corev1.NamespaceList{}
+		if err := c.List(ctx, namespaceList, client.MatchingLabelsSelector{Selector: namespaceSelector}); err != nil {
+			return err
+		}
+		namespaces = map[string]bool{}
+		for _, v := range namespaceList.Items {
+			namespaces[v.Name] = true
+		}
+	}
+
+	if err := util.WaitForCO(ctx, c, logger, "openshift-apiserver", relocation.Spec.Timeouts.GetClusterOperatorSettle()); err != nil {
+		return err
+	}
+
+	exclusions := routeReset.GetExclusions()
+	for _, v := range routes.Items {
+		route := v
+		if isExcluded(exclusions, route.Namespace, route.Name) || (namespaces != nil && !namespaces[route.Namespace]) {
+			continue
+		}
+		for _, w := range route.Status.Ingress {
+			currentHost := route.Spec.Host
+			if currentHost == "" {
+				currentHost = w.Host
+			}
+			// check Routes associated with this Ingress Controller, whose hostname needs to be updated
+			if w.RouterName != routerName || strings.HasSuffix(currentHost, "."+domainName) {
+				continue
+			}
+			domains := fromDomains
+			if _, routerDomain, found := strings.Cut(w.RouterCanonicalHostname, "."); found {
+				domains = append([]string{routerDomain}, fromDomains...)
+			}
+			host, ok := rewriteHost(currentHost, domains, domainName)
+			if !ok {
+				// the hostname isn't in a domain that the relocation moves
+				break
+			}
+			// the API server generates the hostname of a re-created Route in the domain of the cluster Ingress,
+			// so the Routes of the other IngressControllers are always rewritten, otherwise they would never be in their new domain
+			if routeReset.GetPolicy() == rhsysenggithubiov1.RouteResetPolicyDelete && routerName == rhsysenggithubiov1.DefaultIngressController &&
+				route.Annotations[hostGeneratedAnnotation] == "true" {
+				if err := c.Delete(ctx, &route); client.IgnoreNotFound(err) != nil {
+					return err
+				}
+				logger.Info("Deleted Route so that it can be re-created with new domain", "Route", route.Name, "Host", currentHost, "namespace", route.Namespace)
+				recordRoute(relocation, rhsysenggithubiov1.RouteStatus{
+					Namespace:    route.Namespace,
+					Name:         route.Name,
+					RouterName:   routerName,
+					Action:       rhsysenggithubiov1.RouteActionDeleted,
+					OriginalHost: currentHost,
+				})
+				break
+			}
+
+			// a Route with an explicit hostname would be re-created with the same hostname, so it is rewritten instead of deleted
+			patch := client.MergeFrom(route.DeepCopy())
+			route.Spec.Host = host
+			if err := c.Patch(ctx, &route, patch); err != nil {
+				return err
+			}
+			logger.Info("Rewrote the hostname of Route with new domain", "Route", route.Name, "OriginalHost", currentHost, "Host", host, "namespace", route.Namespace)
+			recordRoute(relocation, rhsysenggithubiov1.RouteStatus{
+				Namespace:    route.Namespace,
+				Name:         route.Name,
+				RouterName:   routerName,
+				Action:       rhsysenggithubiov1.RouteActionHostRewritten,
+				OriginalHost: currentHost,
+				Host:         host,
+			})
+			break
+		}
+	}
+	return nil
+}
+
+// RevertRoutes restores the original hostname of the Routes that were rewritten, unless their hostname has been changed since.
+// The Routes that were deleted have been re-created by their owners, so they are reset with the original domain by ResetRoutes instead
+func RevertRoutes(ctx context.Context, c client.Client, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
+	for _, v := range relocation.Status.Routes {
+		if v.Action != rhsysenggithubiov1.RouteActionHostRewritten {
+			continue
+		}
+		route := &routev1.Route{}
+		if err := c.Get(ctx, types.NamespacedName{Name: v.Name, Namespace: v.Namespace}, route); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		if route.Spec.Host != v.Host {
+			logger.Info("Route hostname was changed since it was rewritten, not reverting it", "Route", v.Name, "Host", route.Spec.Host, "namespace", v.Namespace)
+			continue
+		}
+		patch := client.MergeFrom(route.DeepCopy())
+		route.Spec.Host = v.OriginalHost
+		if err := c.Patch(ctx, route, patch); err != nil {
+			return err
+		}
+		logger.Info("Route reverted to original hostname", "Route", v.Name, "Host", v.OriginalHost, "namespace", v.Namespace)
+	}
+	relocation.Status.Routes = nil
+	return nil
+}
+
+// returns true if the Route is excluded, either by its namespace or by <namespace>/<name>
+func isExcluded(exclusions []string, namespace string, name string) bool {
+	for _, v := range exclusions {
+		if v == namespace || v == fmt.Sprintf("%s/%s", namespace, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// returns host with the first of fromDomains that it is in replaced by domainName.
+// Returns false if host isn't in any of fromDomains
+func rewriteHost(host string, fromDomains []string, domainName string) (string, bool) {
+	for _, v := range fromDomains {
+		if v != "" && strings.HasSuffix(host, "."+v) {
+			return fmt.Sprintf("%s.%s", strings.TrimSuffix(host, "."+v), domainName), true
+		}
+	}
+	return "", false
+}
+
+// records a Route that was reset in the status, keeping the hostname that it had before it was first reset
+func recordRoute(relocation *rhsysenggithubiov1.ClusterRelocation, status rhsysenggithubiov1.RouteStatus) {
+	for i, v := range relocation.Status.Routes {
+		if v.Namespace == status.Namespace && v.Name == status.Name {
+			status.OriginalHost = v.OriginalHost
+			relocation.Status.Routes[i] = status
+			return
+		}
+	}
+	relocation.Status.Routes = append(relocation.Status.Routes, status)
+}
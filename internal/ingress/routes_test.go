@@ -0,0 +1,207 @@
+package ingress
+
+import (
+	"testing"
+
+	rhsysenggithubiov1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRewriteHost(t *testing.T) {
+	tests := []struct {
+		name        string
+		host        string
+		fromDomains []string
+		want        string
+		wantOK      bool
+	}{
+		{
+			name:        "router domain",
+			host:        "app-ns.apps.old.example.com",
+			fromDomains: []string{"apps.old.example.com"},
+			want:        "app-ns.apps.new.example.com",
+			wantOK:      true,
+		},
+		{
+			name:        "multi-label prefix",
+			host:        "a.b.apps.old.example.com",
+			fromDomains: []string{"apps.old.example.com"},
+			want:        "a.b.apps.new.example.com",
+			wantOK:      true,
+		},
+		{
+			name:        "first matching domain wins",
+			host:        "app.shard.apps.old.example.com",
+			fromDomains: []string{"shard.apps.old.example.com", "apps.old.example.com"},
+			want:        "app.apps.new.example.com",
+			wantOK:      true,
+		},
+		{
+			name:        "second domain",
+			host:        "app.apps.original.example.com",
+			fromDomains: []string{"apps.old.example.com", "apps.original.example.com"},
+			want:        "app.apps.new.example.com",
+			wantOK:      true,
+		},
+		{
+			name:        "custom domain is not rewritten",
+			host:        "www.customer.example.org",
+			fromDomains: []string{"apps.old.example.com"},
+			wantOK:      false,
+		},
+		{
+			name:        "suffix must be a whole label",
+			host:        "app.myapps.old.example.com",
+			fromDomains: []string{"apps.old.example.com"},
+			wantOK:      false,
+		},
+		{
+			name:        "domain itself is not rewritten",
+			host:        "apps.old.example.com",
+			fromDomains: []string{"apps.old.example.com"},
+			wantOK:      false,
+		},
+		{
+			name:        "empty domains are ignored",
+			host:        "app.apps.old.example.com",
+			fromDomains: []string{"", "apps.old.example.com"},
+			want:        "app.apps.new.example.com",
+			wantOK:      true,
+		},
+		{
+			name:   "no domains",
+			host:   "app.apps.old.example.com",
+			wantOK: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := rewriteHost(tt.host, tt.fromDomains, "apps.new.example.com")
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("rewriteHost(%q, %q) = (%q, %v), want (%q, %v)", tt.host, tt.fromDomains, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestIsExcluded(t *testing.T) {
+	exclusions := []string{"excluded-ns", "app-ns/excluded-route"}
+	tests := []struct {
+		name       string
+		exclusions []string
+		namespace  string
+		routeName  string
+		want       bool
+	}{
+		{
+			name:       "excluded namespace",
+			exclusions: exclusions,
+			namespace:  "excluded-ns",
+			routeName:  "route",
+			want:       true,
+		},
+		{
+			name:       "excluded route",
+			exclusions: exclusions,
+			namespace:  "app-ns",
+			routeName:  "excluded-route",
+			want:       true,
+		},
+		{
+			name:       "other route in the namespace of an excluded route",
+			exclusions: exclusions,
+			namespace:  "app-ns",
+			routeName:  "route",
+			want:       false,
+		},
+		{
+			name:       "route with the name of an excluded route in another namespace",
+			exclusions: exclusions,
+			namespace:  "other-ns",
+			routeName:  "excluded-route",
+			want:       false,
+		},
+		{
+			name:       "route named after an excluded namespace",
+			exclusions: exclusions,
+			namespace:  "other-ns",
+			routeName:  "excluded-ns",
+			want:       false,
+		},
+		{
+			name:      "no exclusions",
+			namespace: "excluded-ns",
+			routeName: "route",
+			want:      false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isExcluded(tt.exclusions, tt.namespace, tt.routeName); got != tt.want {
+				t.Errorf("isExcluded(%q, %q, %q) = %v, want %v", tt.exclusions, tt.namespace, tt.routeName, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordRoute(t *testing.T) {
+	deleted := rhsysenggithubiov1.RouteStatus{
+		Namespace:    "app-ns",
+		Name:         "route",
+		RouterName:   rhsysenggithubiov1.DefaultIngressController,
+		Action:       rhsysenggithubiov1.RouteActionDeleted,
+		OriginalHost: "route-app-ns.apps.original.example.com",
+	}
+	other := rhsysenggithubiov1.RouteStatus{
+		Namespace:    "app-ns",
+		Name:         "other",
+		RouterName:   rhsysenggithubiov1.DefaultIngressController,
+		Action:       rhsysenggithubiov1.RouteActionHostRewritten,
+		OriginalHost: "other.apps.original.example.com",
+		Host:         "other.apps.old.example.com",
+	}
+	rewritten := rhsysenggithubiov1.RouteStatus{
+		Namespace:    "app-ns",
+		Name:         "route",
+		RouterName:   rhsysenggithubiov1.DefaultIngressController,
+		Action:       rhsysenggithubiov1.RouteActionHostRewritten,
+		OriginalHost: "route-app-ns.apps.old.example.com",
+		Host:         "route-app-ns.apps.new.example.com",
+	}
+
+	tests := []struct {
+		name     string
+		existing []rhsysenggithubiov1.RouteStatus
+		status   rhsysenggithubiov1.RouteStatus
+		want     []rhsysenggithubiov1.RouteStatus
+	}{
+		{
+			name:   "new route is appended",
+			status: rewritten,
+			want:   []rhsysenggithubiov1.RouteStatus{rewritten},
+		},
+		{
+			name:     "route reset again keeps the host it had before it was first reset",
+			existing: []rhsysenggithubiov1.RouteStatus{other, deleted},
+			status:   rewritten,
+			want: []rhsysenggithubiov1.RouteStatus{other, {
+				Namespace:    "app-ns",
+				Name:         "route",
+				RouterName:   rhsysenggithubiov1.DefaultIngressController,
+				Action:       rhsysenggithubiov1.RouteActionHostRewritten,
+				OriginalHost: "route-app-ns.apps.original.example.com",
+				Host:         "route-app-ns.apps.new.example.com",
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			relocation := &rhsysenggithubiov1.ClusterRelocation{}
+			relocation.Status.Routes = append(relocation.Status.Routes, tt.existing...)
+			recordRoute(relocation, tt.status)
+			if diff := cmp.Diff(tt.want, relocation.Status.Routes); diff != "" {
+				t.Errorf("unexpected routes (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package ingress
+
+import (
+	"context"
+	"fmt"
+
+	rhsysenggithubiov1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/backup"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/certmanager"
+	secrets "github.com/RHsyseng/cluster-relocation-operator/internal/secrets"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/step"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/util"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/verify"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func init() {
+	step.Register(step.OrderIngress, ingressStep{})
+}
+
+type ingressStep struct{}
+
+func (ingressStep) Name() string { return rhsysenggithubiov1.StepIngress }
+
+func (ingressStep) ConditionType() string { return rhsysenggithubiov1.ConditionTypeIngressReady }
+
+func (ingressStep) FailureReason() string {
+	return rhsysenggithubiov1.IngressReconciliationFailedReason
+}
+
+func (ingressStep) Enabled(relocation *rhsysenggithubiov1.ClusterRelocation) bool {
+	return true
+}
+
+// Applies a new certificate and domain alias to the Ingress, and new certificates to the additional IngressControllers,
+// waits for them to be served, then re-creates the Routes
+func (ingressStep) Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
+	if err := Reconcile(ctx, c, scheme, relocation, logger); err != nil {
+		return err
+	}
+	if err := ReconcileIngressControllers(ctx, c, scheme, relocation, logger); err != nil {
+		return err
+	}
+	roots, err := verify.ExpectedCA(ctx, c, relocation, certmanager.CertRef(relocation, rhsysenggithubiov1.CertificateIngress, relocation.Spec.IngressCertRef))
+	if err != nil {
+		return err
+	}
+	if err := verify.Ingress(ctx, c, relocation, logger, relocation.Spec.Domain, roots); err != nil {
+		return err
+	}
+	// the Routes may also be in the original domain alias of the cluster Ingress
+	var originalAppsDomain string
+	if _, err := backup.Restore(ctx, c, backup.IngressAppsDomainKey, &originalAppsDomain); err != nil {
+		return err
+	}
+	if err := ResetRoutes(ctx, c, relocation, logger, rhsysenggithubiov1.DefaultIngressController, fmt.Sprintf("apps.%s", relocation.Spec.Domain), []string{originalAppsDomain}, nil); err != nil {
+		return err
+	}
+	if len(relocation.Spec.IngressControllers) == 0 {
+		return nil
+	}
+	// the additional IngressControllers are not verified, since their domains may not resolve from the cluster, so the ingress operator must settle instead
+	if err := util.WaitForCO(ctx, c, logger, "ingress", relocation.Spec.Timeouts.GetClusterOperatorSettle()); err != nil {
+		return err
+	}
+	return ResetIngressControllerRoutes(ctx, c, relocation, logger)
+}
+
+// Reverts the Ingress and the additional IngressControllers, waits for the original domain to be served, then re-creates the Routes
+func (ingressStep) Cleanup(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
+	if err := Cleanup(ctx, c, logger); err != nil {
+		return err
+	}
+	if err := CleanupIngressControllers(ctx, c, relocation, logger, nil); err != nil {
+		return err
+	}
+	if err := certmanager.Cleanup(ctx, c, rhsysenggithubiov1.CertificateIngress, nil); err != nil {
+		return err
+	}
+	secrets.ForgetCertificate(relocation, rhsysenggithubiov1.CertificateIngress)
+	baseDomain, err := util.GetBaseDomain(ctx, c)
+	if err != nil {
+		return err
+	}
+	// the original certificate may be signed by any CA, so only its names and expiry are verified
+	if err := verify.Ingress(ctx, c, relocation, logger, baseDomain, nil); err != nil {
+		return err
+	}
+	if err := RevertRoutes(ctx, c, relocation, logger); err != nil {
+		return err
+	}
+	// the original configuration may include a domain alias, which the Routes need to be re-created with
+	appsDomain, err := AppsDomain(ctx, c)
+	if err != nil {
+		return err
+	}
+	if err := ResetRoutes(ctx, c, relocation, logger, rhsysenggithubiov1.DefaultIngressController, appsDomain, []string{fmt.Sprintf("apps.%s", relocation.Spec.Domain)}, nil); err != nil {
+		return err
+	}
+	// the IngressControllers are taken from the spec rather than from the backup, which has already been removed if the step is requeued
+	for _, v := range relocation.Spec.IngressControllers {
+		domain, err := OriginalDomain(ctx, c, v.Name)
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		// the Routes are moved back from the domain that they were relocated to
+		if err := ResetRoutes(ctx, c, relocation, logger, v.Name, domain, []string{v.Domain}, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
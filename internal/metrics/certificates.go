@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var certificateRemainingValidity = prometheus.NewDesc(
+	"cluster_relocation_certificate_remaining_validity_seconds",
+	"Number of seconds until the certificate generated by the cluster relocation operator expires.",
+	[]string{"name", "namespace", "secret"},
+	nil,
+)
+
+type certificate struct {
+	namespace string
+	secret    string
+	notAfter  time.Time
+}
+
+// certificateCollector computes the remaining validity of the certificates when the metrics are scraped,
+// so that it is up to date even though the certificates are only checked when the relocation is reconciled
+type certificateCollector struct {
+	lock         sync.Mutex
+	certificates map[string]certificate
+}
+
+var certificates = &certificateCollector{certificates: map[string]certificate{}}
+
+func init() {
+	metrics.Registry.MustRegister(certificates)
+}
+
+func (c *certificateCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- certificateRemainingValidity
+}
+
+func (c *certificateCollector) Collect(ch chan<- prometheus.Metric) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for name, v := range c.certificates {
+		ch <- prometheus.MustNewConstMetric(certificateRemainingValidity, prometheus.GaugeValue, time.Until(v.notAfter).Seconds(), name, v.namespace, v.secret)
+	}
+}
+
+// SetCertificate records the expiry time of a generated certificate
+func SetCertificate(name string, namespace string, secret string, notAfter time.Time) {
+	certificates.lock.Lock()
+	defer certificates.lock.Unlock()
+
+	certificates.certificates[name] = certificate{namespace: namespace, secret: secret, notAfter: notAfter}
+}
+
+// RetainCertificates stops reporting the certificates which are not in names, once they are no longer generated by the operator
+func RetainCertificates(names map[string]bool) {
+	certificates.lock.Lock()
+	defer certificates.lock.Unlock()
+
+	for name := range certificates.certificates {
+		if !names[name] {
+			delete(certificates.certificates, name)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package migration
+
+import (
+	"context"
+	"time"
+
+	rhsysenggithubiov1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
+	"github.com/go-logr/logr"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+//+kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;list;watch
+//+kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions/status,verbs=update;patch
+
+// CRDName is the name of the ClusterRelocation CRD
+const CRDName = "clusterrelocations.rhsyseng.github.io"
+
+// the migration is retried at this interval until it succeeds, e.g. while the conversion webhook isn't reachable yet
+const retryInterval = time.Minute
+
+// StorageVersionMigrator rewrites the ClusterRelocations that were stored with an older version of the API (e.g. v1beta1),
+// so that they are stored with the current storage version.
+// It then removes the older versions from the storedVersions of the CRD, so that they can eventually be dropped from the CRD.
+type StorageVersionMigrator struct {
+	Client client.Client
+	// Reader reads directly from the API server, since the CRDs aren't cached
+	Reader client.Reader
+	Logger logr.Logger
+}
+
+var _ manager.LeaderElectionRunnable = &StorageVersionMigrator{}
+
+// NeedLeaderElection makes sure that only one instance of the operator migrates the ClusterRelocations
+func (m *StorageVersionMigrator) NeedLeaderElection() bool {
+	return true
+}
+
+// Start implements manager.Runnable. It returns once the migration succeeds, or when ctx is cancelled.
+func (m *StorageVersionMigrator) Start(ctx context.Context) error {
+	err := wait.PollImmediateUntilWithContext(ctx, retryInterval, func(ctx context.Context) (bool, error) {
+		if err := m.migrate(ctx); err != nil {
+			m.Logger.Error(err, "Storage version migration failed, will retry", "RetryInterval", retryInterval)
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
+
+func (m *StorageVersionMigrator) migrate(ctx context.Context) error {
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	if err := m.Reader.Get(ctx, types.NamespacedName{Name: CRDName}, crd); err != nil {
+		return err
+	}
+
+	var storageVersion string
+	for _, v := range crd.Spec.Versions {
+		if v.Storage {
+			storageVersion = v.Name
+		}
+	}
+	if storageVersion != rhsysenggithubiov1.GroupVersion.Version {
+		// the CRD hasn't been upgraded yet, so the objects would be stored with the old version again
+		m.Logger.Info("Skipping storage version migration, the CRD has a different storage version", "StorageVersion", storageVersion)
+		return nil
+	}
+	if len(crd.Status.StoredVersions) == 1 && crd.Status.StoredVersions[0] == storageVersion {
+		return nil
+	}
+
+	relocations := &rhsysenggithubiov1.ClusterRelocationList{}
+	if err := m.Reader.List(ctx, relocations); err != nil {
+		return err
+	}
+	for _, v := range relocations.Items {
+		// an update without any change is enough for the API server to store the object with the storage version
+		if err := m.Client.Update(ctx, &v); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		m.Logger.Info("Migrated ClusterRelocation to the storage version", "ClusterRelocation", v.Name, "StorageVersion", storageVersion)
+	}
+
+	patch := client.MergeFrom(crd.DeepCopy())
+	crd.Status.StoredVersions = []string{storageVersion}
+	if err := m.Client.Status().Patch(ctx, crd, patch); err != nil {
+		return err
+	}
+	m.Logger.Info("Storage version migration completed", "StoredVersions", crd.Status.StoredVersions)
+	return nil
+}
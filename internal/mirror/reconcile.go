@@ -3,7 +3,7 @@ package mirror
 import (
 	"context"
 
-	rhsysenggithubiov1beta1 "github.com/RHsyseng/cluster-relocation-operator/api/v1beta1"
+	rhsysenggithubiov1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
 	"github.com/go-logr/logr"
 	configv1 "github.com/openshift/api/config/v1"
 	operatorv1alpha1 "github.com/openshift/api/operator/v1alpha1"
@@ -20,7 +20,7 @@ import (
 
 const ImageSetName = "mirror-ocp"
 
-func Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1beta1.ClusterRelocation, logger logr.Logger, clusterVersion string) error {
+func Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger, clusterVersion string) error {
 	if relocation.Spec.ImageDigestMirrors == nil {
 		return Cleanup(ctx, c, logger, clusterVersion)
 	}
@@ -47,7 +47,7 @@ func Cleanup(ctx context.Context, c client.Client, logger logr.Logger, clusterVe
 // ImageContentSourcePolicy is deprecated since OCP 4.13
 // This function converts the values in Spec.RepositoryDigestMirrors into an ImageContentSourcePolicy
 // Used for OCP < 4.13
-func createICSP(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1beta1.ClusterRelocation, logger logr.Logger) error {
+func createICSP(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
 	icsp := &operatorv1alpha1.ImageContentSourcePolicy{ObjectMeta: metav1.ObjectMeta{Name: ImageSetName}}
 	op, err := controllerutil.CreateOrUpdate(ctx, c, icsp, func() error {
 		icsp.Spec.RepositoryDigestMirrors = []operatorv1alpha1.RepositoryDigestMirrors{}
@@ -74,7 +74,7 @@ func createICSP(ctx context.Context, c client.Client, scheme *runtime.Scheme, re
 	return nil
 }
 
-func createIDMS(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1beta1.ClusterRelocation, logger logr.Logger) error {
+func createIDMS(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
 	idms := &configv1.ImageDigestMirrorSet{ObjectMeta: metav1.ObjectMeta{Name: ImageSetName}}
 	op, err := controllerutil.CreateOrUpdate(ctx, c, idms, func() error {
 		idms.Spec.ImageDigestMirrors = relocation.Spec.ImageDigestMirrors
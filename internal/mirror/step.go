@@ -0,0 +1,46 @@
+package mirror
+
+import (
+	"context"
+
+	rhsysenggithubiov1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/step"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/util"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func init() {
+	step.Register(step.OrderMirror, mirrorStep{})
+}
+
+type mirrorStep struct{}
+
+func (mirrorStep) Name() string { return rhsysenggithubiov1.StepMirror }
+
+func (mirrorStep) ConditionType() string { return rhsysenggithubiov1.ConditionTypeMirrorReady }
+
+func (mirrorStep) FailureReason() string {
+	return rhsysenggithubiov1.MirrorReconciliationFailedReason
+}
+
+func (mirrorStep) Enabled(relocation *rhsysenggithubiov1.ClusterRelocation) bool {
+	return relocation.Spec.ImageDigestMirrors != nil
+}
+
+func (mirrorStep) Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
+	clusterVersion, err := util.GetClusterVersion(ctx, c)
+	if err != nil {
+		return err
+	}
+	return Reconcile(ctx, c, scheme, relocation, logger, clusterVersion)
+}
+
+func (mirrorStep) Cleanup(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
+	clusterVersion, err := util.GetClusterVersion(ctx, c)
+	if err != nil {
+		return err
+	}
+	return Cleanup(ctx, c, logger, clusterVersion)
+}
@@ -0,0 +1,332 @@
+package plan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	rhsysenggithubiov1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+const (
+	OperationCreate = "Create"
+	OperationUpdate = "Update"
+	OperationPatch  = "Patch"
+	OperationDelete = "Delete"
+)
+
+type objectKey struct {
+	gvk schema.GroupVersionKind
+	key client.ObjectKey
+}
+
+// Client wraps a client.Client so that every write is sent to the API server as a dry run.
+// The same mutate functions that are used when applying the relocation can therefore be used to plan it.
+// Each write that would modify an object is recorded as a PlannedChange.
+//
+// Objects written during the plan are remembered, and returned by subsequent calls to Get,
+// so that each step observes the objects that it would have created or modified.
+// List calls are not affected by the planned writes.
+type Client struct {
+	client.Client
+	dryRun client.Client
+
+	lock    sync.Mutex
+	objects map[objectKey]client.Object
+	created map[objectKey]bool
+	deleted map[objectKey]bool
+	changes []rhsysenggithubiov1.PlannedChange
+}
+
+var _ client.Client = &Client{}
+
+// NewClient returns a Client which plans the writes made through it, instead of applying them
+func NewClient(c client.Client) *Client {
+	return &Client{
+		Client:  c,
+		dryRun:  client.NewDryRunClient(c),
+		objects: map[objectKey]client.Object{},
+		created: map[objectKey]bool{},
+		deleted: map[objectKey]bool{},
+	}
+}
+
+// ForRemote wraps a client for another cluster (e.g. the ACM hub), if c is a planning Client.
+// The writes to the other cluster are recorded along with the local ones. Otherwise, remote is returned as is.
+func ForRemote(c client.Client, remote client.Client) client.Client {
+	planClient, ok := c.(*Client)
+	if !ok {
+		return remote
+	}
+	remotePlanClient := NewClient(remote)
+	return &remoteClient{Client: remotePlanClient, parent: planClient}
+}
+
+// TakeChanges returns the changes recorded since the last call to TakeChanges
+func (p *Client) TakeChanges() []rhsysenggithubiov1.PlannedChange {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	changes := p.changes
+	p.changes = nil
+	return changes
+}
+
+func (p *Client) objectKey(obj client.Object) (objectKey, error) {
+	gvk, err := apiutil.GVKForObject(obj, p.Scheme())
+	if err != nil {
+		return objectKey{}, err
+	}
+	return objectKey{gvk: gvk, key: client.ObjectKeyFromObject(obj)}, nil
+}
+
+func (p *Client) record(key objectKey, operation string, fields []string) {
+	p.changes = append(p.changes, rhsysenggithubiov1.PlannedChange{
+		Operation:  operation,
+		APIVersion: key.gvk.GroupVersion().String(),
+		Kind:       key.gvk.Kind,
+		Namespace:  key.key.Namespace,
+		Name:       key.key.Name,
+		Fields:     fields,
+	})
+}
+
+// Get returns the planned version of the object, if it was written during the plan
+func (p *Client) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	objKey, err := p.objectKey(obj)
+	if err != nil {
+		return err
+	}
+	objKey.key = key
+
+	p.lock.Lock()
+	planned, found := p.objects[objKey]
+	deleted := p.deleted[objKey]
+	p.lock.Unlock()
+
+	if deleted {
+		return errors.NewNotFound(schema.GroupResource{Group: objKey.gvk.Group, Resource: objKey.gvk.Kind}, key.Name)
+	}
+	if found {
+		return copyObject(planned, obj)
+	}
+	return p.Client.Get(ctx, key, obj, opts...)
+}
+
+func (p *Client) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	objKey, err := p.objectKey(obj)
+	if err != nil {
+		return err
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if !p.deleted[objKey] {
+		if _, found := p.objects[objKey]; found {
+			return errors.NewAlreadyExists(schema.GroupResource{Group: objKey.gvk.Group, Resource: objKey.gvk.Kind}, objKey.key.Name)
+		}
+		if err := p.dryRun.Create(ctx, obj, opts...); err != nil {
+			return err
+		}
+	}
+
+	p.objects[objKey] = obj.DeepCopyObject().(client.Object)
+	p.created[objKey] = true
+	delete(p.deleted, objKey)
+	p.record(objKey, OperationCreate, nil)
+	return nil
+}
+
+func (p *Client) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	return p.write(ctx, obj, OperationUpdate, func() error {
+		return p.dryRun.Update(ctx, obj, opts...)
+	})
+}
+
+func (p *Client) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	return p.write(ctx, obj, OperationPatch, func() error {
+		return p.dryRun.Patch(ctx, obj, patch, opts...)
+	})
+}
+
+// write plans an Update or a Patch. obj already holds the desired state of the object
+func (p *Client) write(ctx context.Context, obj client.Object, operation string, dryRun func() error) error {
+	objKey, err := p.objectKey(obj)
+	if err != nil {
+		return err
+	}
+
+	before := obj.DeepCopyObject().(client.Object)
+	if err := p.Get(ctx, objKey.key, before); err != nil {
+		return err
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if !p.created[objKey] {
+		// objects that only exist in the plan can't be sent to the API server
+		if err := dryRun(); err != nil {
+			return err
+		}
+	}
+
+	fields, err := changedFields(before, obj)
+	if err != nil {
+		return err
+	}
+	p.objects[objKey] = obj.DeepCopyObject().(client.Object)
+	if len(fields) > 0 {
+		p.record(objKey, operation, fields)
+	}
+	return nil
+}
+
+func (p *Client) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	objKey, err := p.objectKey(obj)
+	if err != nil {
+		return err
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.deleted[objKey] {
+		return errors.NewNotFound(schema.GroupResource{Group: objKey.gvk.Group, Resource: objKey.gvk.Kind}, objKey.key.Name)
+	}
+	if !p.created[objKey] {
+		if err := p.dryRun.Delete(ctx, obj, opts...); err != nil {
+			return err
+		}
+	}
+
+	delete(p.objects, objKey)
+	delete(p.created, objKey)
+	p.deleted[objKey] = true
+	p.record(objKey, OperationDelete, nil)
+	return nil
+}
+
+func (p *Client) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) error {
+	return fmt.Errorf("DeleteAllOf is not supported while planning")
+}
+
+// Status returns a StatusWriter which sends all writes as a dry run
+func (p *Client) Status() client.StatusWriter {
+	return p.dryRun.Status()
+}
+
+// SubResource returns a SubResourceClient which sends all writes as a dry run
+func (p *Client) SubResource(subResource string) client.SubResourceClient {
+	return p.dryRun.SubResource(subResource)
+}
+
+// remoteClient records the changes of a remote cluster in the plan of its parent
+type remoteClient struct {
+	*Client
+	parent *Client
+}
+
+func (r *remoteClient) flush() {
+	changes := r.Client.TakeChanges()
+	r.parent.lock.Lock()
+	defer r.parent.lock.Unlock()
+	for _, v := range changes {
+		v.Remote = true
+		r.parent.changes = append(r.parent.changes, v)
+	}
+}
+
+func (r *remoteClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	defer r.flush()
+	return r.Client.Create(ctx, obj, opts...)
+}
+
+func (r *remoteClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	defer r.flush()
+	return r.Client.Update(ctx, obj, opts...)
+}
+
+func (r *remoteClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	defer r.flush()
+	return r.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func (r *remoteClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	defer r.flush()
+	return r.Client.Delete(ctx, obj, opts...)
+}
+
+// copies src into dst, which may be of a different type (e.g. typed and unstructured)
+func copyObject(src client.Object, dst client.Object) error {
+	if reflect.TypeOf(src) == reflect.TypeOf(dst) {
+		reflect.ValueOf(dst).Elem().Set(reflect.ValueOf(src.DeepCopyObject()).Elem())
+		return nil
+	}
+	bytes, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bytes, dst)
+}
+
+// metadata which is managed by the API server, and therefore not part of the plan
+var ignoredMetadata = []string{"resourceVersion", "generation", "managedFields", "creationTimestamp", "uid"}
+
+// changedFields returns the paths of the fields which differ between before and after, e.g. spec.servingCerts.namedCertificates.
+// Only the paths are returned, so that the values of Secrets are not exposed.
+func changedFields(before client.Object, after client.Object) ([]string, error) {
+	beforeMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(before)
+	if err != nil {
+		return nil, err
+	}
+	afterMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(after)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range []map[string]interface{}{beforeMap, afterMap} {
+		delete(v, "status")
+		if metadata, ok := v["metadata"].(map[string]interface{}); ok {
+			for _, w := range ignoredMetadata {
+				delete(metadata, w)
+			}
+		}
+	}
+
+	fields := diff("", beforeMap, afterMap)
+	sort.Strings(fields)
+	return fields, nil
+}
+
+func diff(prefix string, before interface{}, after interface{}) []string {
+	beforeMap, beforeIsMap := before.(map[string]interface{})
+	afterMap, afterIsMap := after.(map[string]interface{})
+	if !beforeIsMap || !afterIsMap {
+		if reflect.DeepEqual(before, after) {
+			return nil
+		}
+		return []string{prefix}
+	}
+
+	fields := []string{}
+	keys := map[string]bool{}
+	for k := range beforeMap {
+		keys[k] = true
+	}
+	for k := range afterMap {
+		keys[k] = true
+	}
+	for k := range keys {
+		path := k
+		if prefix != "" {
+			path = fmt.Sprintf("%s.%s", prefix, k)
+		}
+		fields = append(fields, diff(path, beforeMap[k], afterMap[k])...)
+	}
+	return fields
+}
@@ -0,0 +1,182 @@
+package preflight
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	rhsysenggithubiov1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//+kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch
+
+// the cluster DNS service, which is queried when no nameservers are specified
+const (
+	clusterDNSServiceName      = "dns-default"
+	clusterDNSServiceNamespace = "openshift-dns"
+)
+
+// each lookup is given up after this delay, so that an unreachable nameserver doesn't block the reconcile
+const lookupTimeout = 5 * time.Second
+
+// Check is a name to look up, and the addresses that it is expected to resolve to
+type Check struct {
+	Name string
+
+	// Expected is empty if any address is accepted
+	Expected []string
+
+	// Optional checks are reported, but don't fail the preflight
+	Optional bool
+}
+
+// ClusterDNSNameservers returns the address of the cluster DNS service
+func ClusterDNSNameservers(ctx context.Context, c client.Client) ([]string, error) {
+	service := &corev1.Service{}
+	if err := c.Get(ctx, types.NamespacedName{Name: clusterDNSServiceName, Namespace: clusterDNSServiceNamespace}, service); err != nil {
+		return nil, err
+	}
+	if service.Spec.ClusterIP == "" || service.Spec.ClusterIP == corev1.ClusterIPNone {
+		return nil, fmt.Errorf("the %s/%s service has no cluster IP", clusterDNSServiceNamespace, clusterDNSServiceName)
+	}
+	return []string{net.JoinHostPort(service.Spec.ClusterIP, "53")}, nil
+}
+
+// LookupDNS runs every check against every nameserver (<IP> or <IP>:<port>).
+// If there are no nameservers, the resolver of the operator is used.
+// The results are returned in a stable order, so that they can be stored in the status without triggering another reconcile.
+func LookupDNS(ctx context.Context, nameservers []string, checks []Check) []rhsysenggithubiov1.DNSLookup {
+	if len(nameservers) == 0 {
+		nameservers = []string{""}
+	}
+	lookups := []rhsysenggithubiov1.DNSLookup{}
+	for _, nameserver := range nameservers {
+		resolver := newResolver(nameserver)
+		for _, check := range checks {
+			lookups = append(lookups, lookup(ctx, resolver, nameserver, check))
+		}
+	}
+	return lookups
+}
+
+// Error returns an error which describes every lookup that failed, or nil if the preflight succeeded
+func Error(lookups []rhsysenggithubiov1.DNSLookup) error {
+	failures := []string{}
+	for _, v := range lookups {
+		if v.Result == rhsysenggithubiov1.DNSLookupResolved || v.Optional {
+			continue
+		}
+		nameserver := v.Nameserver
+		if nameserver == "" {
+			nameserver = "the default resolver"
+		}
+		failures = append(failures, fmt.Sprintf("%s via %s: %s", v.Name, nameserver, v.Error))
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	return fmt.Errorf("DNS preflight failed: %s", strings.Join(failures, "; "))
+}
+
+func newResolver(nameserver string) *net.Resolver {
+	if nameserver == "" {
+		return net.DefaultResolver
+	}
+	if _, _, err := net.SplitHostPort(nameserver); err != nil {
+		nameserver = net.JoinHostPort(nameserver, "53")
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			// ignore the nameservers of resolv.conf, and always query this one
+			dialer := net.Dialer{}
+			return dialer.DialContext(ctx, network, nameserver)
+		},
+	}
+}
+
+func lookup(ctx context.Context, resolver *net.Resolver, nameserver string, check Check) rhsysenggithubiov1.DNSLookup {
+	result := rhsysenggithubiov1.DNSLookup{
+		Name:       check.Name,
+		Nameserver: nameserver,
+		Expected:   normalize(check.Expected),
+		Optional:   check.Optional,
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, lookupTimeout)
+	defer cancel()
+	ips, err := resolver.LookupIP(ctx, "ip", check.Name)
+	if err != nil {
+		result.Result = rhsysenggithubiov1.DNSLookupFailed
+		result.Error = lookupError(err)
+		return result
+	}
+	addresses := []string{}
+	for _, v := range ips {
+		addresses = append(addresses, v.String())
+	}
+	result.Addresses = normalize(addresses)
+
+	unexpected := []string{}
+	if len(result.Expected) > 0 {
+		for _, v := range result.Addresses {
+			if !contains(result.Expected, v) {
+				unexpected = append(unexpected, v)
+			}
+		}
+	}
+	if len(unexpected) > 0 {
+		result.Result = rhsysenggithubiov1.DNSLookupMismatch
+		result.Error = fmt.Sprintf("resolved to %s, expected %s", strings.Join(unexpected, ", "), strings.Join(result.Expected, ", "))
+		return result
+	}
+	result.Result = rhsysenggithubiov1.DNSLookupResolved
+	return result
+}
+
+// returns a message which doesn't change between attempts, unlike the errors of the resolver which may include ports or IDs
+func lookupError(err error) string {
+	if dnsErr, ok := err.(*net.DNSError); ok {
+		switch {
+		case dnsErr.IsNotFound:
+			return "no such host"
+		case dnsErr.IsTimeout:
+			return "timed out"
+		}
+		return dnsErr.Err
+	}
+	return err.Error()
+}
+
+// returns the canonical form of the IP addresses, sorted and without duplicates
+func normalize(addresses []string) []string {
+	if len(addresses) == 0 {
+		return nil
+	}
+	normalized := []string{}
+	for _, v := range addresses {
+		if ip := net.ParseIP(v); ip != nil {
+			v = ip.String()
+		}
+		if !contains(normalized, v) {
+			normalized = append(normalized, v)
+		}
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -4,7 +4,7 @@ import (
 	"context"
 	"fmt"
 
-	rhsysenggithubiov1beta1 "github.com/RHsyseng/cluster-relocation-operator/api/v1beta1"
+	rhsysenggithubiov1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
 	secrets "github.com/RHsyseng/cluster-relocation-operator/internal/secrets"
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
@@ -17,7 +17,7 @@ import (
 
 //+kubebuilder:rbac:groups="",resources=secrets,verbs=get;delete;list;watch
 
-func Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1beta1.ClusterRelocation, logger logr.Logger) error {
+func Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
 	if relocation.Spec.PullSecretRef == nil {
 		// run Cleanup function in case they are moving from PullSecretRef=<something> to PullSecretRef=<empty>
 		return Cleanup(ctx, c, scheme, relocation, logger)
@@ -32,7 +32,7 @@ func Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, rel
 	}
 
 	backupPullSecret := &corev1.Secret{}
-	if err := c.Get(ctx, types.NamespacedName{Name: rhsysenggithubiov1beta1.BackupPullSecretName, Namespace: rhsysenggithubiov1beta1.ConfigNamespace}, backupPullSecret); err != nil {
+	if err := c.Get(ctx, types.NamespacedName{Name: rhsysenggithubiov1.BackupPullSecretName, Namespace: rhsysenggithubiov1.ConfigNamespace}, backupPullSecret); err != nil {
 		if errors.IsNotFound(err) {
 			// if we haven't yet made a backup of the original pull secret, make one now
 			// we should own the backup secret, but not the original pull-secret
@@ -42,7 +42,7 @@ func Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, rel
 				OwnDestination:               true,
 				DestinationOwnedByController: true,
 			}
-			op, err := secrets.CopySecret(ctx, c, relocation, scheme, rhsysenggithubiov1beta1.PullSecretName, rhsysenggithubiov1beta1.ConfigNamespace, rhsysenggithubiov1beta1.BackupPullSecretName, rhsysenggithubiov1beta1.ConfigNamespace, copySettings)
+			op, err := secrets.CopySecret(ctx, c, relocation, scheme, rhsysenggithubiov1.PullSecretName, rhsysenggithubiov1.ConfigNamespace, rhsysenggithubiov1.BackupPullSecretName, rhsysenggithubiov1.ConfigNamespace, copySettings)
 			if err != nil {
 				return err
 			}
@@ -62,7 +62,7 @@ func Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, rel
 		OwnDestination:               false,
 		DestinationOwnedByController: false,
 	}
-	op, err := secrets.CopySecret(ctx, c, relocation, scheme, relocation.Spec.PullSecretRef.Name, relocation.Spec.PullSecretRef.Namespace, rhsysenggithubiov1beta1.PullSecretName, rhsysenggithubiov1beta1.ConfigNamespace, copySettings)
+	op, err := secrets.CopySecret(ctx, c, relocation, scheme, relocation.Spec.PullSecretRef.Name, relocation.Spec.PullSecretRef.Namespace, rhsysenggithubiov1.PullSecretName, rhsysenggithubiov1.ConfigNamespace, copySettings)
 	if err != nil {
 		return err
 	}
@@ -72,10 +72,10 @@ func Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, rel
 	return nil
 }
 
-func Cleanup(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1beta1.ClusterRelocation, logger logr.Logger) error {
+func Cleanup(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
 	// If we modified the original pull secret, we need to restore it
 	backupPullSecret := &corev1.Secret{}
-	if err := c.Get(ctx, types.NamespacedName{Name: rhsysenggithubiov1beta1.BackupPullSecretName, Namespace: rhsysenggithubiov1beta1.ConfigNamespace}, backupPullSecret); err != nil {
+	if err := c.Get(ctx, types.NamespacedName{Name: rhsysenggithubiov1.BackupPullSecretName, Namespace: rhsysenggithubiov1.ConfigNamespace}, backupPullSecret); err != nil {
 		// if there is no backup, that means we didn't modify the pull-secret. Nothing for us to do
 		if !errors.IsNotFound(err) {
 			return err
@@ -85,7 +85,7 @@ func Cleanup(ctx context.Context, c client.Client, scheme *runtime.Scheme, reloc
 		// the backup should already be owned by the controller
 		// we should not own the cluster-wide pull secret
 		copySettings := secrets.SecretCopySettings{}
-		op, err := secrets.CopySecret(ctx, c, relocation, scheme, rhsysenggithubiov1beta1.BackupPullSecretName, rhsysenggithubiov1beta1.ConfigNamespace, rhsysenggithubiov1beta1.PullSecretName, rhsysenggithubiov1beta1.ConfigNamespace, copySettings)
+		op, err := secrets.CopySecret(ctx, c, relocation, scheme, rhsysenggithubiov1.BackupPullSecretName, rhsysenggithubiov1.ConfigNamespace, rhsysenggithubiov1.PullSecretName, rhsysenggithubiov1.ConfigNamespace, copySettings)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,39 @@
+package pullsecret
+
+import (
+	"context"
+
+	rhsysenggithubiov1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/step"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func init() {
+	step.Register(step.OrderPullSecret, pullSecretStep{})
+}
+
+type pullSecretStep struct{}
+
+func (pullSecretStep) Name() string { return rhsysenggithubiov1.StepPullSecret }
+
+func (pullSecretStep) ConditionType() string {
+	return rhsysenggithubiov1.ConditionTypePullSecretReady
+}
+
+func (pullSecretStep) FailureReason() string {
+	return rhsysenggithubiov1.PullSecretReconciliationFailedReason
+}
+
+func (pullSecretStep) Enabled(relocation *rhsysenggithubiov1.ClusterRelocation) bool {
+	return relocation.Spec.PullSecretRef != nil
+}
+
+func (pullSecretStep) Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
+	return Reconcile(ctx, c, scheme, relocation, logger)
+}
+
+func (pullSecretStep) Cleanup(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
+	return Cleanup(ctx, c, scheme, relocation, logger)
+}
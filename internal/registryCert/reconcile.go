@@ -4,12 +4,14 @@ import (
 	"context"
 	"fmt"
 
-	rhsysenggithubiov1beta1 "github.com/RHsyseng/cluster-relocation-operator/api/v1beta1"
+	rhsysenggithubiov1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/backup"
 	"github.com/go-logr/logr"
 	configv1 "github.com/openshift/api/config/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
@@ -19,19 +21,21 @@ import (
 
 const ConfigMapName = "generated-registry-cert"
 
-func Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1beta1.ClusterRelocation, logger logr.Logger) error {
-	if relocation.Spec.RegistryCert == nil {
+func Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
+	if len(relocation.Spec.RegistryCerts) == 0 {
 		return Cleanup(ctx, c, logger)
 	}
 
-	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: ConfigMapName, Namespace: rhsysenggithubiov1beta1.ConfigNamespace}}
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: ConfigMapName, Namespace: rhsysenggithubiov1.ConfigNamespace}}
 	op, err := controllerutil.CreateOrUpdate(ctx, c, configMap, func() error {
-		var port string
-		if relocation.Spec.RegistryCert.RegistryPort != nil {
-			port = fmt.Sprintf("..%d", *relocation.Spec.RegistryCert.RegistryPort)
-		}
-		configMap.Data = map[string]string{
-			fmt.Sprintf("%s%s", relocation.Spec.RegistryCert.RegistryHostname, port): relocation.Spec.RegistryCert.Certificate,
+		// each key of the ConfigMap is a registry, with the port separated by '..'
+		configMap.Data = map[string]string{}
+		for _, v := range relocation.Spec.RegistryCerts {
+			var port string
+			if v.RegistryPort != nil {
+				port = fmt.Sprintf("..%d", *v.RegistryPort)
+			}
+			configMap.Data[fmt.Sprintf("%s%s", v.RegistryHostname, port)] = v.Certificate
 		}
 		// Set the controller as the owner so that the ConfigMap is deleted along with the CR
 		return controllerutil.SetControllerReference(relocation, configMap, scheme)
@@ -43,7 +47,16 @@ func Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, rel
 		logger.Info("Registry certificate modified", "OperationResult", op)
 	}
 
-	imageConfig := &configv1.Image{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}}
+	imageConfig := &configv1.Image{}
+	if err := c.Get(ctx, types.NamespacedName{Name: "cluster"}, imageConfig); err != nil {
+		return err
+	}
+	// save the original trusted CA, so that it can be restored when the CR is deleted.
+	// If it is already our ConfigMap, the original is unknown, so no trusted CA is restored instead
+	relocated := imageConfig.Spec.AdditionalTrustedCA.Name == ConfigMapName
+	if err := backup.SaveOriginal(ctx, c, scheme, relocation, logger, backup.ImageAdditionalTrustedCAKey, imageConfig.Spec.AdditionalTrustedCA, configv1.ConfigMapNameReference{}, relocated); err != nil {
+		return err
+	}
 	op, err = controllerutil.CreateOrPatch(ctx, c, imageConfig, func() error {
 		imageConfig.Spec.AdditionalTrustedCA = configv1.ConfigMapNameReference{Name: ConfigMapName}
 		return nil
@@ -59,10 +72,20 @@ func Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, rel
 
 func Cleanup(ctx context.Context, c client.Client, logger logr.Logger) error {
 	// if they move from relocation.Spec.RegistryCert.Certificate=<something> to relocation.Spec.RegistryCert.Certificate=<empty>
-	// we need to clear out the AdditionalTrustedCA
+	// we need to put the AdditionalTrustedCA back the way we found it
+	additionalTrustedCA := configv1.ConfigMapNameReference{}
+	found, err := backup.Restore(ctx, c, backup.ImageAdditionalTrustedCAKey, &additionalTrustedCA)
+	if err != nil {
+		return err
+	}
+	if !found {
+		// if there is no backup, that means we didn't modify the AdditionalTrustedCA. Nothing for us to do
+		return nil
+	}
+
 	imageConfig := &configv1.Image{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}}
 	op, err := controllerutil.CreateOrPatch(ctx, c, imageConfig, func() error {
-		imageConfig.Spec.AdditionalTrustedCA = configv1.ConfigMapNameReference{}
+		imageConfig.Spec.AdditionalTrustedCA = additionalTrustedCA
 		return nil
 	})
 	if err != nil {
@@ -71,5 +94,5 @@ func Cleanup(ctx context.Context, c client.Client, logger logr.Logger) error {
 	if op != controllerutil.OperationResultNone {
 		logger.Info("AdditionalTrustedCA reverted to original state", "OperationResult", op)
 	}
-	return nil
+	return backup.Remove(ctx, c, backup.ImageAdditionalTrustedCAKey)
 }
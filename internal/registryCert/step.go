@@ -0,0 +1,39 @@
+package registrycert
+
+import (
+	"context"
+
+	rhsysenggithubiov1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/step"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func init() {
+	step.Register(step.OrderRegistryCert, registryCertStep{})
+}
+
+type registryCertStep struct{}
+
+func (registryCertStep) Name() string { return rhsysenggithubiov1.StepRegistryCert }
+
+func (registryCertStep) ConditionType() string {
+	return rhsysenggithubiov1.ConditionTypeRegistryCertReady
+}
+
+func (registryCertStep) FailureReason() string {
+	return rhsysenggithubiov1.RegistryReconciliationFailedReason
+}
+
+func (registryCertStep) Enabled(relocation *rhsysenggithubiov1.ClusterRelocation) bool {
+	return len(relocation.Spec.RegistryCerts) > 0
+}
+
+func (registryCertStep) Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
+	return Reconcile(ctx, c, scheme, relocation, logger)
+}
+
+func (registryCertStep) Cleanup(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
+	return Cleanup(ctx, c, logger)
+}
@@ -0,0 +1,147 @@
+package certs
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"time"
+
+	rhsysenggithubiov1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ManagedCASecretName is the name of the secret in openshift-config which holds the CA created by the operator
+const ManagedCASecretName = "relocation-ca"
+
+// the managed CA outlives the certificates that it signs, which are renewed on their own
+const managedCAValidity = 10 * 365 * 24 * time.Hour
+
+// Signer is the CA which signs the generated certificates
+type Signer struct {
+	Certificate    *x509.Certificate
+	CertificatePEM []byte
+	PrivateKey     crypto.Signer
+}
+
+// Fingerprint returns the SHA-256 fingerprint of the certificate of the CA
+func (s *Signer) Fingerprint() string {
+	return fmt.Sprintf("%x", sha256.Sum256(s.Certificate.Raw))
+}
+
+// GetSigner returns the CA selected by the CertificateGeneration settings of the relocation
+func GetSigner(ctx context.Context, c client.Client, relocation *rhsysenggithubiov1.ClusterRelocation) (*Signer, error) {
+	var key types.NamespacedName
+	switch relocation.Spec.CertificateGeneration.GetCAType() {
+	case rhsysenggithubiov1.CATypeManaged:
+		key = types.NamespacedName{Name: ManagedCASecretName, Namespace: rhsysenggithubiov1.ConfigNamespace}
+	case rhsysenggithubiov1.CATypeUser:
+		secretRef := relocation.Spec.CertificateGeneration.CA.SecretRef
+		if secretRef == nil {
+			return nil, fmt.Errorf("must specify the secret of the CA")
+		}
+		key = types.NamespacedName{Name: secretRef.Name, Namespace: secretRef.Namespace}
+	default:
+		key = types.NamespacedName{Name: "loadbalancer-serving-signer", Namespace: "openshift-kube-apiserver-operator"}
+	}
+
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, key, secret); err != nil {
+		return nil, err
+	}
+	signer, err := ParseSigner(secret.Data)
+	if err != nil {
+		return nil, fmt.Errorf("could not use secret %s/%s as a CA: %w", key.Namespace, key.Name, err)
+	}
+	return signer, nil
+}
+
+// ParseSigner decodes the CA certificate and private key of a TLS secret
+func ParseSigner(data map[string][]byte) (*Signer, error) {
+	certBlock, _ := pem.Decode(data[corev1.TLSCertKey])
+	if certBlock == nil {
+		return nil, fmt.Errorf("could not decode certificate")
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	if !cert.IsCA {
+		return nil, fmt.Errorf("the certificate is not a CA")
+	}
+	keyBlock, _ := pem.Decode(data[corev1.TLSPrivateKeyKey])
+	if keyBlock == nil {
+		return nil, fmt.Errorf("could not decode private key")
+	}
+	privateKey, err := parsePrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	return &Signer{
+		Certificate:    cert,
+		CertificatePEM: pem.EncodeToMemory(certBlock),
+		PrivateKey:     privateKey,
+	}, nil
+}
+
+// GenerateCA generates a self-signed CA, with the key settings of the CertificateGeneration
+func GenerateCA(commonName string, options *rhsysenggithubiov1.CertificateGeneration) (map[string][]byte, error) {
+	privateKey, privateKeyPEM, err := generatePrivateKey(options.GetKeyAlgorithm(), options.GetKeySize())
+	if err != nil {
+		return nil, err
+	}
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	certificateTemplate := x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             now,
+		NotAfter:              now.Add(managedCAValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	derBytes, err := x509.CreateCertificate(rand.Reader, &certificateTemplate, &certificateTemplate, privateKey.Public(), privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string][]byte{
+		corev1.TLSCertKey:       pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: derBytes}),
+		corev1.TLSPrivateKeyKey: privateKeyPEM,
+	}, nil
+}
+
+// parses a private key in the PKCS #1, SEC 1 or PKCS #8 format
+func parsePrivateKey(der []byte) (crypto.Signer, error) {
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse private key")
+	}
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		return key, nil
+	case *ecdsa.PrivateKey:
+		return key, nil
+	}
+	return nil, fmt.Errorf("unsupported private key type %T", key)
+}
@@ -2,16 +2,23 @@ package certs
 
 import (
 	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/json"
 	"encoding/pem"
 	"fmt"
 	"math/big"
+	"net"
 	"time"
 
-	rhsysenggithubiov1beta1 "github.com/RHsyseng/cluster-relocation-operator/api/v1beta1"
+	rhsysenggithubiov1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/metrics"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -29,69 +36,108 @@ type SecretCopySettings struct {
 
 //+kubebuilder:rbac:groups="",resources=secrets,verbs=get;create;update;list;watch
 
-func GetCertCommonName(TLSCertKey []byte) (string, error) {
-	block, _ := pem.Decode(TLSCertKey)
-	if block == nil {
-		return "", fmt.Errorf("failed to decode certificate")
-	}
+// CertificateHashAnnotation is set on the generated TLS secrets. It holds the hash of the CertificateRequest that the certificate was generated from,
+// so that the certificate is generated again when the request changes
+const CertificateHashAnnotation = "rhsyseng.github.io/certificate-hash"
 
-	// Parse the certificate
-	cert, err := x509.ParseCertificate(block.Bytes)
-	if err != nil {
-		return "", err
-	}
+// CertificateRequest describes a certificate to generate
+type CertificateRequest struct {
+	CommonName   string                          `json:"commonName"`
+	DNSNames     []string                        `json:"dnsNames"`
+	IPAddresses  []string                        `json:"ipAddresses,omitempty"`
+	KeyAlgorithm rhsysenggithubiov1.KeyAlgorithm `json:"keyAlgorithm"`
+	KeySize      int32                           `json:"keySize"`
+	Validity     time.Duration                   `json:"validity"`
 
-	return cert.Subject.CommonName, nil
+	// Issuer is the fingerprint of the CA, so that the certificate is generated again when the CA changes
+	Issuer string `json:"issuer"`
 }
 
-func GenerateTLSKeyPair(ctx context.Context, c client.Client, domain string, prefix string) (map[string][]byte, error) {
-	// Sign the certificate using loadbalancer-serving-signer
-	lbSigningSecret := &corev1.Secret{}
-	if err := c.Get(ctx, types.NamespacedName{Name: "loadbalancer-serving-signer", Namespace: "openshift-kube-apiserver-operator"}, lbSigningSecret); err != nil {
-		return nil, err
+// NewCertificateRequest returns the request for a certificate for <prefix>.<domain>, along with the additional names (DNS names or IP addresses),
+// signed by signer
+func NewCertificateRequest(domain string, prefix string, additionalNames []string, options *rhsysenggithubiov1.CertificateGeneration, signer *Signer) CertificateRequest {
+	commonName := fmt.Sprintf("%s.%s", prefix, domain)
+	request := CertificateRequest{
+		CommonName:   commonName,
+		DNSNames:     []string{commonName},
+		KeyAlgorithm: options.GetKeyAlgorithm(),
+		KeySize:      options.GetKeySize(),
+		Validity:     options.GetValidity(),
+		Issuer:       signer.Fingerprint(),
 	}
-	certBlock, _ := pem.Decode(lbSigningSecret.Data[corev1.TLSCertKey])
-	if certBlock == nil {
-		return nil, fmt.Errorf("could not decode loadbalancer-serving-signer certificate")
+	for _, v := range additionalNames {
+		if ip := net.ParseIP(v); ip != nil {
+			if !contains(request.IPAddresses, ip.String()) {
+				request.IPAddresses = append(request.IPAddresses, ip.String())
+			}
+		} else if !contains(request.DNSNames, v) {
+			request.DNSNames = append(request.DNSNames, v)
+		}
 	}
-	lbSigningCert, err := x509.ParseCertificate(certBlock.Bytes)
-	if err != nil {
-		return nil, err
+	return request
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
 	}
-	keyBlock, _ := pem.Decode(lbSigningSecret.Data[corev1.TLSPrivateKeyKey])
-	if keyBlock == nil {
-		return nil, fmt.Errorf("could not decode loadbalancer-serving-signer private key")
+	return false
+}
+
+// Hash returns a hash of the request, which changes whenever the generated certificate would be different
+func (r CertificateRequest) Hash() (string, error) {
+	bytes, err := json.Marshal(r)
+	if err != nil {
+		return "", err
 	}
-	lbSigningPrivateKey, err := x509.ParsePKCS1PrivateKey(keyBlock.Bytes)
+	return fmt.Sprintf("%x", sha256.Sum256(bytes)), nil
+}
+
+// GenerateTLSKeyPair generates a certificate for the request, signed by signer
+func GenerateTLSKeyPair(signer *Signer, request CertificateRequest) (map[string][]byte, error) {
+	// Generate a private key
+	privateKey, privateKeyPEM, err := generatePrivateKey(request.KeyAlgorithm, request.KeySize)
 	if err != nil {
 		return nil, err
 	}
+	keyUsage := x509.KeyUsageDigitalSignature
+	if request.KeyAlgorithm == rhsysenggithubiov1.KeyAlgorithmRSA {
+		// RSA keys are also used for key exchange by the older TLS cipher suites
+		keyUsage |= x509.KeyUsageKeyEncipherment
+	}
 
-	// Generate a private key
-	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	// Serial numbers must be unique for the CA, so they are random
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
 	if err != nil {
 		return nil, err
 	}
 
 	// Create a certificate template
+	now := time.Now()
 	certificateTemplate := x509.Certificate{
-		SerialNumber:          big.NewInt(1),
-		Subject:               pkix.Name{CommonName: fmt.Sprintf("%s.%s", prefix, domain)},
-		NotBefore:             time.Now(),
-		NotAfter:              time.Now().Add(3650 * 24 * time.Hour), // Valid for 10 years
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: request.CommonName},
+		NotBefore:             now,
+		NotAfter:              now.Add(request.Validity),
+		KeyUsage:              keyUsage,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
 		BasicConstraintsValid: true,
-		DNSNames:              []string{fmt.Sprintf("%s.%s", prefix, domain)},
+		DNSNames:              request.DNSNames,
+	}
+	for _, v := range request.IPAddresses {
+		certificateTemplate.IPAddresses = append(certificateTemplate.IPAddresses, net.ParseIP(v))
 	}
 
-	// Create a certificate using the private key and certificate template, signed by loadbalancer-serving-signer
-	derBytes, err := x509.CreateCertificate(rand.Reader, &certificateTemplate, lbSigningCert, &privateKey.PublicKey, lbSigningPrivateKey)
+	// Create a certificate using the private key and certificate template, signed by the CA
+	derBytes, err := x509.CreateCertificate(rand.Reader, &certificateTemplate, signer.Certificate, privateKey.Public(), signer.PrivateKey)
 	if err != nil {
 		return nil, err
 	}
 
 	// Create PEM blocks for the certificate and private key
 	certificatePEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: derBytes})
-	privateKeyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)})
 
 	return map[string][]byte{
 		corev1.TLSCertKey:       certificatePEM,
@@ -99,8 +145,122 @@ func GenerateTLSKeyPair(ctx context.Context, c client.Client, domain string, pre
 	}, nil
 }
 
+// GetCertNotAfter returns the expiry time of the first certificate of TLSCertKey
+func GetCertNotAfter(TLSCertKey []byte) (time.Time, error) {
+	block, _ := pem.Decode(TLSCertKey)
+	if block == nil {
+		return time.Time{}, fmt.Errorf("failed to decode certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return cert.NotAfter, nil
+}
+
+// NeedsRenewal returns true if the certificate of the TLS secret expires within renewBefore
+func NeedsRenewal(secret *corev1.Secret, renewBefore time.Duration) (bool, error) {
+	notAfter, err := GetCertNotAfter(secret.Data[corev1.TLSCertKey])
+	if err != nil {
+		return false, err
+	}
+	return !time.Now().Before(notAfter.Add(-renewBefore)), nil
+}
+
+// RecordCertificate reports the expiry and renewal times of a generated certificate in the status
+func RecordCertificate(relocation *rhsysenggithubiov1.ClusterRelocation, name string, secret *corev1.Secret, renewBefore time.Duration) error {
+	notAfter, err := GetCertNotAfter(secret.Data[corev1.TLSCertKey])
+	if err != nil {
+		return err
+	}
+	status := rhsysenggithubiov1.CertificateStatus{
+		Name:        name,
+		SecretRef:   corev1.SecretReference{Name: secret.Name, Namespace: secret.Namespace},
+		NotAfter:    metav1.NewTime(notAfter),
+		RenewalTime: metav1.NewTime(notAfter.Add(-renewBefore)),
+	}
+	found := false
+	for i, v := range relocation.Status.Certificates {
+		if v.Name == name {
+			relocation.Status.Certificates[i] = status
+			found = true
+		}
+	}
+	if !found {
+		relocation.Status.Certificates = append(relocation.Status.Certificates, status)
+	}
+	return nil
+}
+
+// ForgetCertificate removes a certificate from the status, once it is no longer generated by the operator
+func ForgetCertificate(relocation *rhsysenggithubiov1.ClusterRelocation, name string) {
+	certificates := []rhsysenggithubiov1.CertificateStatus{}
+	for _, v := range relocation.Status.Certificates {
+		if v.Name != name {
+			certificates = append(certificates, v)
+		}
+	}
+	if len(certificates) == 0 {
+		certificates = nil
+	}
+	relocation.Status.Certificates = certificates
+}
+
+// ReportCertificates reports the certificates of the status in the metrics, and stops reporting the ones which are no longer in the status
+func ReportCertificates(relocation *rhsysenggithubiov1.ClusterRelocation) {
+	names := map[string]bool{}
+	for _, v := range relocation.Status.Certificates {
+		metrics.SetCertificate(v.Name, v.SecretRef.Namespace, v.SecretRef.Name, v.NotAfter.Time)
+		names[v.Name] = true
+	}
+	metrics.RetainCertificates(names)
+}
+
+// NextRenewal returns the earliest renewal time of the generated certificates, or the zero time if there are none
+func NextRenewal(relocation *rhsysenggithubiov1.ClusterRelocation) time.Time {
+	var next time.Time
+	for _, v := range relocation.Status.Certificates {
+		if next.IsZero() || v.RenewalTime.Time.Before(next) {
+			next = v.RenewalTime.Time
+		}
+	}
+	return next
+}
+
+// returns a new private key, and its PEM encoding
+func generatePrivateKey(algorithm rhsysenggithubiov1.KeyAlgorithm, size int32) (crypto.Signer, []byte, error) {
+	switch algorithm {
+	case rhsysenggithubiov1.KeyAlgorithmRSA:
+		privateKey, err := rsa.GenerateKey(rand.Reader, int(size))
+		if err != nil {
+			return nil, nil, err
+		}
+		return privateKey, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)}), nil
+	case rhsysenggithubiov1.KeyAlgorithmECDSA:
+		var curve elliptic.Curve
+		switch size {
+		case 256:
+			curve = elliptic.P256()
+		case 384:
+			curve = elliptic.P384()
+		default:
+			return nil, nil, fmt.Errorf("unsupported ECDSA key size %d", size)
+		}
+		privateKey, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			return nil, nil, err
+		}
+		bytes, err := x509.MarshalECPrivateKey(privateKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		return privateKey, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: bytes}), nil
+	}
+	return nil, nil, fmt.Errorf("unsupported key algorithm %s", algorithm)
+}
+
 // copies a secret from one location to another
-func CopySecret(ctx context.Context, c client.Client, relocation *rhsysenggithubiov1beta1.ClusterRelocation, scheme *runtime.Scheme,
+func CopySecret(ctx context.Context, c client.Client, relocation *rhsysenggithubiov1.ClusterRelocation, scheme *runtime.Scheme,
 	origSecretName string, origSecretNamespace string, destSecretName string, destSecretNamespace string, settings SecretCopySettings,
 ) (controllerutil.OperationResult, error) {
 	origSecret := &corev1.Secret{}
@@ -144,7 +304,7 @@ func CopySecret(ctx context.Context, c client.Client, relocation *rhsysenggithub
 	return op, err
 }
 
-func ValidateSecretType(ctx context.Context, c client.Client, ref *corev1.SecretReference, desiredSecretType corev1.SecretType) error {
+func ValidateSecretType(ctx context.Context, c client.Reader, ref *corev1.SecretReference, desiredSecretType corev1.SecretType) error {
 	secret := &corev1.Secret{}
 	if err := c.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: ref.Namespace}, secret); err != nil {
 		return err
@@ -0,0 +1,155 @@
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	rhsysenggithubiov1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// the key of a TLS secret which may contain the CA certificates that signed tls.crt
+const caCertKey = "ca.crt"
+
+// InvalidCertificateError is returned when the certificate of a TLS secret can't be served for the relocated domain.
+// Its condition reason is InvalidCertificate, rather than the failure reason of the step
+type InvalidCertificateError struct {
+	Secret  types.NamespacedName
+	Message string
+}
+
+func (e *InvalidCertificateError) Error() string {
+	return fmt.Sprintf("invalid certificate in secret %s: %s", e.Secret, e.Message)
+}
+
+// Reason returns the condition reason of the error
+func (e *InvalidCertificateError) Reason() string {
+	return rhsysenggithubiov1.InvalidCertificateReason
+}
+
+// ValidateTLSSecret checks that the TLS secret holds a certificate which can be served for hostname:
+// the private key must match the certificate, its subject alternative names must cover hostname,
+// the certificate and its chain must be currently valid, and the chain must lead to the TrustedRoots of the secret
+func ValidateTLSSecret(ctx context.Context, c client.Reader, ref *corev1.SecretReference, hostname string) error {
+	if err := ValidateSecretType(ctx, c, ref, corev1.SecretTypeTLS); err != nil {
+		return err
+	}
+	secret := &corev1.Secret{}
+	key := types.NamespacedName{Name: ref.Name, Namespace: ref.Namespace}
+	if err := c.Get(ctx, key, secret); err != nil {
+		return err
+	}
+	invalid := func(format string, args ...interface{}) error {
+		return &InvalidCertificateError{Secret: key, Message: fmt.Sprintf(format, args...)}
+	}
+
+	// this parses tls.crt and tls.key, and checks that the private key matches the first certificate
+	keyPair, err := tls.X509KeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+	if err != nil {
+		return invalid("%s", err.Error())
+	}
+	certs := []*x509.Certificate{}
+	for _, v := range keyPair.Certificate {
+		cert, err := x509.ParseCertificate(v)
+		if err != nil {
+			return invalid("could not parse %s: %s", corev1.TLSCertKey, err.Error())
+		}
+		certs = append(certs, cert)
+	}
+	leaf := certs[0]
+
+	if err := leaf.VerifyHostname(hostname); err != nil {
+		return invalid("the certificate is not valid for %s: %s", hostname, err.Error())
+	}
+
+	now := time.Now()
+	for _, v := range certs {
+		if now.After(v.NotAfter) {
+			return invalid("the certificate %q expired at %s", v.Subject.String(), v.NotAfter.UTC().Format(time.RFC3339))
+		}
+		if now.Before(v.NotBefore) {
+			return invalid("the certificate %q is not valid before %s", v.Subject.String(), v.NotBefore.UTC().Format(time.RFC3339))
+		}
+	}
+
+	roots, err := TrustedRoots(secret)
+	if err != nil {
+		return invalid("%s", err.Error())
+	}
+	intermediates := x509.NewCertPool()
+	for _, v := range certs[1:] {
+		intermediates.AddCert(v)
+	}
+	opts := x509.VerifyOptions{Roots: roots, Intermediates: intermediates, CurrentTime: now, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}}
+	if _, err := leaf.Verify(opts); err != nil {
+		unknownAuthority := x509.UnknownAuthorityError{}
+		if errors.As(err, &unknownAuthority) {
+			// the intermediate certificates must be included in tls.crt, since they are served along with the certificate
+			return invalid("the certificate chain is incomplete, add the missing intermediate certificates to %s, or the CA to %s: %s", corev1.TLSCertKey, caCertKey, err.Error())
+		}
+		return invalid("could not verify the certificate chain: %s", err.Error())
+	}
+	return nil
+}
+
+// ValidateHostname checks that the certificate of a TLS secret is valid for hostname.
+// Unlike ValidateTLSSecret, the key and the chain are not checked, e.g. when the secret has already been validated for another hostname
+func ValidateHostname(ctx context.Context, c client.Reader, ref *corev1.SecretReference, hostname string) error {
+	secret := &corev1.Secret{}
+	key := types.NamespacedName{Name: ref.Name, Namespace: ref.Namespace}
+	if err := c.Get(ctx, key, secret); err != nil {
+		return err
+	}
+	block, _ := pem.Decode(secret.Data[corev1.TLSCertKey])
+	if block == nil {
+		return &InvalidCertificateError{Secret: key, Message: fmt.Sprintf("could not decode %s", corev1.TLSCertKey)}
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return &InvalidCertificateError{Secret: key, Message: fmt.Sprintf("could not parse %s: %s", corev1.TLSCertKey, err.Error())}
+	}
+	if err := cert.VerifyHostname(hostname); err != nil {
+		return &InvalidCertificateError{Secret: key, Message: fmt.Sprintf("the certificate is not valid for %s: %s", hostname, err.Error())}
+	}
+	return nil
+}
+
+// TrustedRoots returns the CA certificates that the certificate of a TLS secret is expected to be signed by.
+// This is the ca.crt of the secret if it has one, or else the system roots along with the CA certificates included in tls.crt
+func TrustedRoots(secret *corev1.Secret) (*x509.CertPool, error) {
+	if caCert, ok := secret.Data[caCertKey]; ok {
+		roots := x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("could not decode %s of secret %s/%s", caCertKey, secret.Namespace, secret.Name)
+		}
+		return roots, nil
+	}
+
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		return nil, err
+	}
+	rest := secret.Data[corev1.TLSCertKey]
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		if cert.IsCA {
+			roots.AddCert(cert)
+		}
+	}
+	return roots, nil
+}
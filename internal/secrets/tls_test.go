@@ -0,0 +1,280 @@
+package certs
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// secretReader is a client.Reader which serves a fixed set of Secrets
+type secretReader map[client.ObjectKey]*corev1.Secret
+
+func (r secretReader) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	secret, ok := r[key]
+	if !ok {
+		return apierrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, key.Name)
+	}
+	secret.DeepCopyInto(obj.(*corev1.Secret))
+	return nil
+}
+
+func (r secretReader) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	return errors.New("not implemented")
+}
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func (c testCert) certPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw})
+}
+
+func (c testCert) keyPEM(t *testing.T) []byte {
+	der, err := x509.MarshalECPrivateKey(c.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+}
+
+// returns a certificate signed by parent, or a self-signed one if parent is nil
+func newTestCert(t *testing.T, parent *testCert, template *x509.Certificate) testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatal(err)
+	}
+	template.SerialNumber = serial
+	if template.NotBefore.IsZero() {
+		template.NotBefore = time.Now().Add(-time.Hour)
+	}
+	if template.NotAfter.IsZero() {
+		template.NotAfter = time.Now().Add(time.Hour)
+	}
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testCert{cert: cert, key: key}
+}
+
+func newCATemplate(name string) *x509.Certificate {
+	return &x509.Certificate{
+		Subject:               pkix.Name{CommonName: name},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+}
+
+func newServingTemplate(dnsNames ...string) *x509.Certificate {
+	return &x509.Certificate{
+		Subject:     pkix.Name{CommonName: dnsNames[0]},
+		DNSNames:    dnsNames,
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+}
+
+func TestValidateTLSSecret(t *testing.T) {
+	root := newTestCert(t, nil, newCATemplate("root"))
+	intermediate := newTestCert(t, &root, newCATemplate("intermediate"))
+	leaf := newTestCert(t, &root, newServingTemplate("*.apps.new.example.com"))
+	leafFromIntermediate := newTestCert(t, &intermediate, newServingTemplate("*.apps.new.example.com"))
+	other := newTestCert(t, &root, newServingTemplate("*.apps.new.example.com"))
+
+	expiredTemplate := newServingTemplate("*.apps.new.example.com")
+	expiredTemplate.NotBefore = time.Now().Add(-48 * time.Hour)
+	expiredTemplate.NotAfter = time.Now().Add(-24 * time.Hour)
+	expired := newTestCert(t, &root, expiredTemplate)
+
+	notYetValidTemplate := newServingTemplate("*.apps.new.example.com")
+	notYetValidTemplate.NotBefore = time.Now().Add(24 * time.Hour)
+	notYetValidTemplate.NotAfter = time.Now().Add(48 * time.Hour)
+	notYetValid := newTestCert(t, &root, notYetValidTemplate)
+
+	clientTemplate := newServingTemplate("*.apps.new.example.com")
+	clientTemplate.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	clientOnly := newTestCert(t, &root, clientTemplate)
+
+	join := func(pems ...[]byte) []byte {
+		var data []byte
+		for _, v := range pems {
+			data = append(data, v...)
+		}
+		return data
+	}
+
+	tests := []struct {
+		name       string
+		secretType corev1.SecretType
+		data       map[string][]byte
+		hostname   string
+		// a substring of the expected error, or empty if the secret is valid
+		wantErr string
+		// whether the error is an InvalidCertificateError
+		wantInvalid bool
+	}{
+		{
+			name:     "valid with ca.crt",
+			data:     map[string][]byte{"tls.crt": leaf.certPEM(), "tls.key": leaf.keyPEM(t), "ca.crt": root.certPEM()},
+			hostname: "console-openshift-console.apps.new.example.com",
+		},
+		{
+			name:     "valid with the intermediate in tls.crt",
+			data:     map[string][]byte{"tls.crt": join(leafFromIntermediate.certPEM(), intermediate.certPEM()), "tls.key": leafFromIntermediate.keyPEM(t), "ca.crt": root.certPEM()},
+			hostname: "console-openshift-console.apps.new.example.com",
+		},
+		{
+			name:     "valid with the root in tls.crt",
+			data:     map[string][]byte{"tls.crt": join(leaf.certPEM(), root.certPEM()), "tls.key": leaf.keyPEM(t)},
+			hostname: "console-openshift-console.apps.new.example.com",
+		},
+		{
+			name:       "wrong secret type",
+			secretType: corev1.SecretTypeOpaque,
+			data:       map[string][]byte{"tls.crt": leaf.certPEM(), "tls.key": leaf.keyPEM(t), "ca.crt": root.certPEM()},
+			hostname:   "console-openshift-console.apps.new.example.com",
+			wantErr:    "should be kubernetes.io/tls",
+		},
+		{
+			name:        "key mismatch",
+			data:        map[string][]byte{"tls.crt": leaf.certPEM(), "tls.key": other.keyPEM(t), "ca.crt": root.certPEM()},
+			hostname:    "console-openshift-console.apps.new.example.com",
+			wantErr:     "private key does not match public key",
+			wantInvalid: true,
+		},
+		{
+			name:        "missing key",
+			data:        map[string][]byte{"tls.crt": leaf.certPEM(), "ca.crt": root.certPEM()},
+			hostname:    "console-openshift-console.apps.new.example.com",
+			wantErr:     "failed to find any PEM data in key input",
+			wantInvalid: true,
+		},
+		{
+			name:        "hostname not in the SANs",
+			data:        map[string][]byte{"tls.crt": leaf.certPEM(), "tls.key": leaf.keyPEM(t), "ca.crt": root.certPEM()},
+			hostname:    "api.new.example.com",
+			wantErr:     "not valid for api.new.example.com",
+			wantInvalid: true,
+		},
+		{
+			name:        "wildcard only covers one label",
+			data:        map[string][]byte{"tls.crt": leaf.certPEM(), "tls.key": leaf.keyPEM(t), "ca.crt": root.certPEM()},
+			hostname:    "a.b.apps.new.example.com",
+			wantErr:     "not valid for a.b.apps.new.example.com",
+			wantInvalid: true,
+		},
+		{
+			name:        "expired",
+			data:        map[string][]byte{"tls.crt": expired.certPEM(), "tls.key": expired.keyPEM(t), "ca.crt": root.certPEM()},
+			hostname:    "console-openshift-console.apps.new.example.com",
+			wantErr:     "expired at",
+			wantInvalid: true,
+		},
+		{
+			name:        "not yet valid",
+			data:        map[string][]byte{"tls.crt": notYetValid.certPEM(), "tls.key": notYetValid.keyPEM(t), "ca.crt": root.certPEM()},
+			hostname:    "console-openshift-console.apps.new.example.com",
+			wantErr:     "is not valid before",
+			wantInvalid: true,
+		},
+		{
+			name:        "incomplete chain",
+			data:        map[string][]byte{"tls.crt": leafFromIntermediate.certPEM(), "tls.key": leafFromIntermediate.keyPEM(t), "ca.crt": root.certPEM()},
+			hostname:    "console-openshift-console.apps.new.example.com",
+			wantErr:     "the certificate chain is incomplete",
+			wantInvalid: true,
+		},
+		{
+			name:        "signed by another CA than ca.crt",
+			data:        map[string][]byte{"tls.crt": leaf.certPEM(), "tls.key": leaf.keyPEM(t), "ca.crt": intermediate.certPEM()},
+			hostname:    "console-openshift-console.apps.new.example.com",
+			wantErr:     "the certificate chain is incomplete",
+			wantInvalid: true,
+		},
+		{
+			name:        "self-signed without ca.crt",
+			data:        map[string][]byte{"tls.crt": leaf.certPEM(), "tls.key": leaf.keyPEM(t)},
+			hostname:    "console-openshift-console.apps.new.example.com",
+			wantErr:     "the certificate chain is incomplete",
+			wantInvalid: true,
+		},
+		{
+			name:        "invalid ca.crt",
+			data:        map[string][]byte{"tls.crt": leaf.certPEM(), "tls.key": leaf.keyPEM(t), "ca.crt": []byte("not a certificate")},
+			hostname:    "console-openshift-console.apps.new.example.com",
+			wantErr:     "could not decode ca.crt",
+			wantInvalid: true,
+		},
+		{
+			name:        "not a serving certificate",
+			data:        map[string][]byte{"tls.crt": clientOnly.certPEM(), "tls.key": clientOnly.keyPEM(t), "ca.crt": root.certPEM()},
+			hostname:    "console-openshift-console.apps.new.example.com",
+			wantErr:     "could not verify the certificate chain",
+			wantInvalid: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secretType := tt.secretType
+			if secretType == "" {
+				secretType = corev1.SecretTypeTLS
+			}
+			ref := &corev1.SecretReference{Name: "ingress-cert", Namespace: "openshift-config"}
+			secret := &corev1.Secret{Type: secretType, Data: tt.data}
+			secret.Name, secret.Namespace = ref.Name, ref.Namespace
+			c := secretReader{client.ObjectKeyFromObject(secret): secret}
+
+			err := ValidateTLSSecret(context.Background(), c, ref, tt.hostname)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+			invalidErr := &InvalidCertificateError{}
+			if errors.As(err, &invalidErr) != tt.wantInvalid {
+				t.Errorf("expected InvalidCertificateError to be %v, got %T", tt.wantInvalid, err)
+			}
+		})
+	}
+}
+
+func TestValidateTLSSecretNotFound(t *testing.T) {
+	ref := &corev1.SecretReference{Name: "ingress-cert", Namespace: "openshift-config"}
+	err := ValidateTLSSecret(context.Background(), secretReader{}, ref, "console-openshift-console.apps.new.example.com")
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected a NotFound error, got %v", err)
+	}
+}
@@ -3,9 +3,9 @@ package ssh
 import (
 	"context"
 	"encoding/json"
-	"fmt"
+	"strings"
 
-	rhsysenggithubiov1beta1 "github.com/RHsyseng/cluster-relocation-operator/api/v1beta1"
+	rhsysenggithubiov1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
 	"github.com/go-logr/logr"
 	machineconfigurationv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -31,13 +31,22 @@ type MachineConfigData struct {
 
 //+kubebuilder:rbac:groups=machineconfiguration.openshift.io,resources=machineconfigs,verbs=create;update;get;delete;list;watch
 
-func Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1beta1.ClusterRelocation, logger logr.Logger) error {
-	if relocation.Spec.SSHKeys == nil {
-		return Cleanup(ctx, c, logger)
+// the roles of the MachineConfigPools which receive the keys, unless the spec lists other roles
+var defaultRoles = []string{"master", "worker"}
+
+const machineConfigPrefix = "core-ssh-key-"
+
+func Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
+	if relocation.Spec.SSH == nil {
+		return Cleanup(ctx, c, relocation, logger)
 	}
 
-	for _, v := range []string{"master", "worker"} {
-		machineConfig := &machineconfigurationv1.MachineConfig{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("core-ssh-key-%s", v)}}
+	roles := relocation.Spec.SSH.Roles
+	if len(roles) == 0 {
+		roles = defaultRoles
+	}
+	for _, v := range roles {
+		machineConfig := &machineconfigurationv1.MachineConfig{ObjectMeta: metav1.ObjectMeta{Name: machineConfigPrefix + v}}
 		op, err := controllerutil.CreateOrUpdate(ctx, c, machineConfig, func() error {
 			machineConfig.Labels = map[string]string{"machineconfiguration.openshift.io/role": v}
 			configData := MachineConfigData{
@@ -46,7 +55,7 @@ func Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, rel
 					Users: []MachineConfigUsersData{
 						{
 							Name:              "core",
-							SSHAuthorizedKeys: relocation.Spec.SSHKeys,
+							SSHAuthorizedKeys: relocation.Spec.SSH.AuthorizedKeys,
 						},
 					},
 				},
@@ -67,19 +76,41 @@ func Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, rel
 		}
 	}
 
-	return nil
+	// if a role is removed from the spec, its MachineConfig needs to be deleted
+	return deleteMachineConfigs(ctx, c, relocation, roles, logger)
+}
+
+func Cleanup(ctx context.Context, c client.Client, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
+	// if they move from relocation.Spec.SSH=<something> to relocation.Spec.SSH=<empty>, we need to delete the MachineConfigs
+	return deleteMachineConfigs(ctx, c, relocation, nil, logger)
 }
 
-func Cleanup(ctx context.Context, c client.Client, logger logr.Logger) error {
-	// if they move from relocation.Spec.SSHKeys=<something> to relocation.Spec.SSHKeys=<empty>, we need to delete the MachineConfigs
-	for _, v := range []string{"master", "worker"} {
-		machineConfig := &machineconfigurationv1.MachineConfig{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("core-ssh-key-%s", v)}}
-		if err := c.Delete(ctx, machineConfig); err != nil {
+// deletes the SSH key MachineConfigs controlled by the CR, except the ones of the roles in keep.
+// The MachineConfigs with the same prefix which were created by the cluster owner are left as is
+func deleteMachineConfigs(ctx context.Context, c client.Client, relocation *rhsysenggithubiov1.ClusterRelocation, keep []string, logger logr.Logger) error {
+	machineConfigs := &machineconfigurationv1.MachineConfigList{}
+	if err := c.List(ctx, machineConfigs); err != nil {
+		return err
+	}
+	for _, v := range machineConfigs.Items {
+		if !strings.HasPrefix(v.Name, machineConfigPrefix) || !metav1.IsControlledBy(&v, relocation) {
+			continue
+		}
+		var keepMachineConfig bool
+		for _, role := range keep {
+			if v.Name == machineConfigPrefix+role {
+				keepMachineConfig = true
+			}
+		}
+		if keepMachineConfig {
+			continue
+		}
+		if err := c.Delete(ctx, &v); err != nil {
 			if !errors.IsNotFound(err) {
 				return err
 			}
 		} else {
-			logger.Info("SSH key MachineConfig deleted", "MachineConfig", machineConfig.ObjectMeta.Name)
+			logger.Info("SSH key MachineConfig deleted", "MachineConfig", v.Name)
 		}
 	}
 	return nil
@@ -0,0 +1,35 @@
+package ssh
+
+import (
+	"context"
+
+	rhsysenggithubiov1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/step"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func init() {
+	step.Register(step.OrderSSH, sshStep{})
+}
+
+type sshStep struct{}
+
+func (sshStep) Name() string { return rhsysenggithubiov1.StepSSH }
+
+func (sshStep) ConditionType() string { return rhsysenggithubiov1.ConditionTypeSSHReady }
+
+func (sshStep) FailureReason() string { return rhsysenggithubiov1.SSHReconciliationFailedReason }
+
+func (sshStep) Enabled(relocation *rhsysenggithubiov1.ClusterRelocation) bool {
+	return relocation.Spec.SSH != nil
+}
+
+func (sshStep) Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
+	return Reconcile(ctx, c, scheme, relocation, logger)
+}
+
+func (sshStep) Cleanup(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error {
+	return Cleanup(ctx, c, relocation, logger)
+}
@@ -0,0 +1,100 @@
+package step
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	rhsysenggithubiov1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Step is a single part of the relocation, such as configuring the API server or registering to ACM.
+// Steps are reconciled in ascending order, and cleaned up in the reverse order.
+type Step interface {
+	// Name uniquely identifies the step. It is used as the key in Status.Steps.
+	Name() string
+
+	// ConditionType is the type of the status condition that reports the state of this step.
+	ConditionType() string
+
+	// FailureReason is the condition reason that is reported when the step fails.
+	FailureReason() string
+
+	// Enabled returns whether the ClusterRelocation configures this step.
+	// When a step is not enabled, Cleanup is called instead of Reconcile,
+	// so that the step can undo its changes if it was previously enabled.
+	Enabled(relocation *rhsysenggithubiov1.ClusterRelocation) bool
+
+	// Reconcile applies the changes made by this step.
+	Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error
+
+	// Cleanup reverts the changes made by this step.
+	Cleanup(ctx context.Context, c client.Client, scheme *runtime.Scheme, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger) error
+}
+
+// The order of the built-in steps. They are spaced apart so that additional steps can be registered between them.
+const (
+	OrderDNS          = 100
+	OrderSSH          = 200
+	OrderRegistryCert = 300
+	OrderMirror       = 400
+	OrderPullSecret   = 500
+	OrderCatalog      = 600
+	OrderCA           = 650
+	OrderIngress      = 700
+	OrderAPI          = 800
+	OrderACM          = 900
+)
+
+type registration struct {
+	order int
+	step  Step
+}
+
+var (
+	registryLock sync.Mutex
+	registry     []registration
+)
+
+// Register adds a step to the relocation. This is normally called from the init function of the package implementing the step.
+// Steps with the same order are run in the order in which they were registered.
+func Register(order int, s Step) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	for _, v := range registry {
+		if v.step.Name() == s.Name() {
+			panic(fmt.Sprintf("relocation step %s registered twice", s.Name()))
+		}
+	}
+	registry = append(registry, registration{order: order, step: s})
+	sort.SliceStable(registry, func(i, j int) bool {
+		return registry[i].order < registry[j].order
+	})
+}
+
+// Steps returns the registered steps, in the order in which they should be reconciled.
+func Steps() []Step {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	steps := make([]Step, 0, len(registry))
+	for _, v := range registry {
+		steps = append(steps, v.step)
+	}
+	return steps
+}
+
+// CleanupSteps returns the registered steps, in the order in which they should be cleaned up.
+// This is the reverse of the order returned by Steps.
+func CleanupSteps() []Step {
+	steps := Steps()
+	for i, j := 0, len(steps)-1; i < j; i, j = i+1, j-1 {
+		steps[i], steps[j] = steps[j], steps[i]
+	}
+	return steps
+}
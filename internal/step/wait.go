@@ -0,0 +1,73 @@
+package step
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultRequeueAfter is how long a waiting step is given before it is run again
+const DefaultRequeueAfter = 10 * time.Second
+
+// WaitingError is returned by a step when it has to wait for the cluster to converge
+// (e.g. for a ClusterOperator to settle or for a MachineConfigPool to update).
+// Rather than blocking the reconcile, the step is run again after RequeueAfter.
+type WaitingError struct {
+	// For describes what the step is waiting for.
+	// It may change while the step waits (e.g. from one ClusterOperator to the next), without restarting the wait.
+	For string
+
+	// Timeout is how long the step may wait, since it started waiting, before it fails. Zero means that there is no timeout.
+	Timeout time.Duration
+
+	// RequeueAfter is how long to wait before running the step again.
+	RequeueAfter time.Duration
+}
+
+func (e *WaitingError) Error() string {
+	return fmt.Sprintf("waiting for %s", e.For)
+}
+
+// Wait returns a WaitingError for the given description, which is checked again after DefaultRequeueAfter
+func Wait(format string, args ...interface{}) *WaitingError {
+	return &WaitingError{For: fmt.Sprintf(format, args...), RequeueAfter: DefaultRequeueAfter}
+}
+
+// WithTimeout sets the timeout of the WaitingError
+func (e *WaitingError) WithTimeout(timeout time.Duration) *WaitingError {
+	e.Timeout = timeout
+	return e
+}
+
+// AsWaitingError returns the WaitingError wrapped by err, if there is one
+func AsWaitingError(err error) (*WaitingError, bool) {
+	waitingErr := &WaitingError{}
+	if errors.As(err, &waitingErr) {
+		return waitingErr, true
+	}
+	return nil, false
+}
+
+// ReasonError is implemented by the errors which have a more specific condition reason than the FailureReason of the step
+type ReasonError interface {
+	error
+	Reason() string
+}
+
+// AsReasonError returns the ReasonError wrapped by err, if there is one
+func AsReasonError(err error) (ReasonError, bool) {
+	var reasonErr ReasonError
+	if errors.As(err, &reasonErr) {
+		return reasonErr, true
+	}
+	return nil, false
+}
+
+// TimeoutError is returned when a step has been waiting for longer than the timeout of its WaitingError
+type TimeoutError struct {
+	Waiting *WaitingError
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("timed out after %s %s", e.Waiting.Timeout, e.Waiting.Error())
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/RHsyseng/cluster-relocation-operator/internal/step"
 	"github.com/go-logr/logr"
 	configv1 "github.com/openshift/api/config/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -13,43 +14,47 @@ import (
 
 //+kubebuilder:rbac:groups=config.openshift.io,resources=clusteroperators,verbs=get;list;watch
 
-// Waits for the operator to update before returning
-func WaitForCO(ctx context.Context, c client.Client, logger logr.Logger, operator string) error {
-	logger.Info(fmt.Sprintf("Waiting for %s Progressing to be %s", operator, configv1.ConditionFalse))
-	if err := waitStatus(ctx, c, logger, operator, configv1.OperatorProgressing, configv1.ConditionFalse); err != nil {
+// Returns nil once the operator has settled, or a WaitingError if it is still updating
+func WaitForCO(ctx context.Context, c client.Client, logger logr.Logger, operator string, timeout time.Duration) error {
+	if err := checkStatus(ctx, c, logger, operator, configv1.OperatorProgressing, configv1.ConditionFalse, timeout); err != nil {
 		return err
 	}
 
-	logger.Info(fmt.Sprintf("Waiting for %s Available to be %s", operator, configv1.ConditionTrue))
-	if err := waitStatus(ctx, c, logger, operator, configv1.OperatorAvailable, configv1.ConditionTrue); err != nil {
+	return checkStatus(ctx, c, logger, operator, configv1.OperatorAvailable, configv1.ConditionTrue, timeout)
+}
+
+func checkStatus(ctx context.Context, c client.Client, logger logr.Logger, operator string, statusType configv1.ClusterStatusConditionType, desired configv1.ConditionStatus, timeout time.Duration) error {
+	co := &configv1.ClusterOperator{}
+	if err := c.Get(ctx, types.NamespacedName{Name: operator}, co); err != nil {
 		return err
 	}
-	return nil
+	for _, v := range co.Status.Conditions {
+		if v.Type == statusType && v.Status == desired {
+			return nil
+		}
+	}
+	logger.Info(fmt.Sprintf("Still waiting for %s %s to be %s", operator, statusType, desired))
+	return step.Wait("ClusterOperator %s %s to be %s", operator, statusType, desired).WithTimeout(timeout)
 }
 
-func waitStatus(ctx context.Context, c client.Client, logger logr.Logger, operator string, statusType configv1.ClusterStatusConditionType, desired configv1.ConditionStatus) error {
-	startTime := time.Now()
-	for {
-		co := &configv1.ClusterOperator{}
-		if err := c.Get(ctx, types.NamespacedName{Name: operator}, co); err != nil {
-			return err
-		}
-		desiredStatus := false
-		for _, v := range co.Status.Conditions {
-			if v.Type == statusType && v.Status != desired {
-				logger.Info(fmt.Sprintf("Still waiting for %s %s to be %s", operator, statusType, desired))
-				time.Sleep(time.Second * 10)
-			} else if v.Type == statusType && v.Status == desired {
-				desiredStatus = true
-			}
-		}
-		if desiredStatus {
-			break
-		}
-		// we set a 5 minute timeout in case the operator never gets to Progressing
-		if time.Since(startTime) > time.Minute*5 && statusType == configv1.OperatorProgressing && desired == configv1.ConditionTrue {
-			break
-		}
+//+kubebuilder:rbac:groups=config.openshift.io,resources=clusterversions,verbs=get;watch;list
+
+// Returns the desired cluster version, in semver format (e.g. v4.13.0)
+func GetClusterVersion(ctx context.Context, c client.Client) (string, error) {
+	clusterVersion := &configv1.ClusterVersion{}
+	if err := c.Get(ctx, types.NamespacedName{Name: "version"}, clusterVersion); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("v%s", clusterVersion.Status.Desired.Version), nil
+}
+
+//+kubebuilder:rbac:groups=config.openshift.io,resources=dnses,verbs=get;watch;list
+
+// Returns the original base domain of the cluster
+func GetBaseDomain(ctx context.Context, c client.Client) (string, error) {
+	clusterDNS := &configv1.DNS{}
+	if err := c.Get(ctx, types.NamespacedName{Name: "cluster"}, clusterDNS); err != nil {
+		return "", err
 	}
-	return nil
+	return clusterDNS.Spec.BaseDomain, nil
 }
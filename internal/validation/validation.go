@@ -0,0 +1,588 @@
+package validation
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	rhsysenggithubiov1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
+	secrets "github.com/RHsyseng/cluster-relocation-operator/internal/secrets"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// The name of the only ClusterRelocation that is reconciled.
+// This makes it so that only 1 CR is reconciled per cluster (since the CR is cluster-scoped)
+const RelocationName = "cluster"
+
+// Validate checks the ClusterRelocation for errors which can be detected without looking at the cluster
+func Validate(relocation *rhsysenggithubiov1.ClusterRelocation) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if relocation.Name != RelocationName {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("metadata", "name"), relocation.Name, fmt.Sprintf("CR name must be: %s", RelocationName)))
+	}
+
+	specPath := field.NewPath("spec")
+	spec := relocation.Spec
+	allErrs = append(allErrs, validateDomain(specPath.Child("domain"), spec.Domain)...)
+	allErrs = append(allErrs, validateSecretReference(specPath.Child("apiCertRef"), spec.APICertRef)...)
+	allErrs = append(allErrs, validateSecretReference(specPath.Child("ingressCertRef"), spec.IngressCertRef)...)
+	allErrs = append(allErrs, validateSecretReference(specPath.Child("pullSecretRef"), spec.PullSecretRef)...)
+
+	registries := map[string]bool{}
+	for i, v := range spec.RegistryCerts {
+		registryCertPath := specPath.Child("registryCerts").Index(i)
+		if v.RegistryHostname == "" {
+			allErrs = append(allErrs, field.Required(registryCertPath.Child("registryHostname"), ""))
+		} else if strings.Contains(v.RegistryHostname, ":") {
+			allErrs = append(allErrs, field.Invalid(registryCertPath.Child("registryHostname"), v.RegistryHostname, "must not include a port, use registryPort instead"))
+		}
+		registry := v.RegistryHostname
+		if v.RegistryPort != nil {
+			for _, msg := range validation.IsValidPortNum(*v.RegistryPort) {
+				allErrs = append(allErrs, field.Invalid(registryCertPath.Child("registryPort"), *v.RegistryPort, msg))
+			}
+			registry = fmt.Sprintf("%s:%d", v.RegistryHostname, *v.RegistryPort)
+		}
+		if registries[registry] {
+			// the certificates are stored in a ConfigMap, keyed by registry
+			allErrs = append(allErrs, field.Duplicate(registryCertPath, registry))
+		}
+		registries[registry] = true
+		if err := validateCertificates(v.Certificate); err != nil {
+			allErrs = append(allErrs, field.Invalid(registryCertPath.Child("certificate"), "<certificate>", err.Error()))
+		}
+	}
+
+	if spec.SSH != nil {
+		sshPath := specPath.Child("ssh")
+		if len(spec.SSH.AuthorizedKeys) == 0 {
+			allErrs = append(allErrs, field.Required(sshPath.Child("authorizedKeys"), ""))
+		}
+		for i, v := range spec.SSH.AuthorizedKeys {
+			if err := validateAuthorizedKey(v); err != nil {
+				allErrs = append(allErrs, field.Invalid(sshPath.Child("authorizedKeys").Index(i), v, err.Error()))
+			}
+		}
+		roles := map[string]bool{}
+		for i, v := range spec.SSH.Roles {
+			// the role is used in the name of the MachineConfig
+			for _, msg := range validation.IsDNS1123Label(v) {
+				allErrs = append(allErrs, field.Invalid(sshPath.Child("roles").Index(i), v, msg))
+			}
+			if roles[v] {
+				allErrs = append(allErrs, field.Duplicate(sshPath.Child("roles").Index(i), v))
+			}
+			roles[v] = true
+		}
+	}
+
+	if spec.DNS != nil {
+		allErrs = append(allErrs, validateDNS(specPath.Child("dns"), spec.DNS)...)
+	}
+
+	if spec.CertificateGeneration != nil {
+		allErrs = append(allErrs, validateCertificateGeneration(specPath.Child("certificateGeneration"), spec.CertificateGeneration)...)
+	}
+
+	if spec.API != nil {
+		additionalNamesPath := specPath.Child("api", "additionalNames")
+		hostnames := map[string]bool{fmt.Sprintf("api.%s", spec.Domain): true}
+		for i, v := range spec.API.AdditionalNames {
+			// the names are served with their own URL, so they must be hostnames rather than wildcards
+			for _, msg := range validation.IsDNS1123Subdomain(v) {
+				allErrs = append(allErrs, field.Invalid(additionalNamesPath.Index(i), v, msg))
+			}
+			if hostnames[v] {
+				allErrs = append(allErrs, field.Duplicate(additionalNamesPath.Index(i), v))
+			}
+			hostnames[v] = true
+		}
+	}
+
+	ingressControllers := map[string]bool{}
+	for i, v := range spec.IngressControllers {
+		ingressControllerPath := specPath.Child("ingressControllers").Index(i)
+		// the name is used in the names of the generated secrets and cert-manager Certificates
+		for _, msg := range validation.IsDNS1123Subdomain(v.Name) {
+			allErrs = append(allErrs, field.Invalid(ingressControllerPath.Child("name"), v.Name, msg))
+		}
+		if v.Name == rhsysenggithubiov1.DefaultIngressController {
+			allErrs = append(allErrs, field.Forbidden(ingressControllerPath.Child("name"), "the default IngressController is relocated with spec.domain and spec.ingressCertRef"))
+		}
+		if ingressControllers[v.Name] {
+			allErrs = append(allErrs, field.Duplicate(ingressControllerPath.Child("name"), v.Name))
+		}
+		ingressControllers[v.Name] = true
+		allErrs = append(allErrs, validateDomain(ingressControllerPath.Child("domain"), v.Domain)...)
+		allErrs = append(allErrs, validateSecretReference(ingressControllerPath.Child("certRef"), v.CertRef)...)
+		if v.RouteSelector != nil {
+			if _, err := metav1.LabelSelectorAsSelector(v.RouteSelector); err != nil {
+				allErrs = append(allErrs, field.Invalid(ingressControllerPath.Child("routeSelector"), v.RouteSelector, err.Error()))
+			}
+		}
+	}
+
+	componentRoutes := map[string]bool{}
+	for i, v := range spec.ComponentRoutes {
+		componentRoutePath := specPath.Child("componentRoutes").Index(i)
+		if v.Name == "" {
+			allErrs = append(allErrs, field.Required(componentRoutePath.Child("name"), ""))
+		}
+		for _, msg := range validation.IsDNS1123Label(v.Namespace) {
+			allErrs = append(allErrs, field.Invalid(componentRoutePath.Child("namespace"), v.Namespace, msg))
+		}
+		key := fmt.Sprintf("%s/%s", v.Namespace, v.Name)
+		if componentRoutes[key] {
+			allErrs = append(allErrs, field.Duplicate(componentRoutePath, key))
+		}
+		componentRoutes[key] = true
+		if v.Hostname != "" {
+			// a component route is served on a single hostname, so wildcards are not allowed
+			for _, msg := range validation.IsDNS1123Subdomain(v.Hostname) {
+				allErrs = append(allErrs, field.Invalid(componentRoutePath.Child("hostname"), v.Hostname, msg))
+			}
+		}
+		allErrs = append(allErrs, validateSecretReference(componentRoutePath.Child("certRef"), v.CertRef)...)
+	}
+
+	if spec.RouteReset != nil {
+		allErrs = append(allErrs, validateRouteReset(specPath.Child("routeReset"), spec.RouteReset)...)
+	}
+
+	if spec.CertManager != nil {
+		allErrs = append(allErrs, validateCertManager(specPath.Child("certManager"), spec.CertManager)...)
+	}
+
+	if spec.Verification != nil {
+		verificationPath := specPath.Child("verification")
+		if spec.Verification.APIPort != nil {
+			for _, msg := range validation.IsValidPortNum(int(*spec.Verification.APIPort)) {
+				allErrs = append(allErrs, field.Invalid(verificationPath.Child("apiPort"), *spec.Verification.APIPort, msg))
+			}
+		}
+		if spec.Verification.IngressPort != nil {
+			for _, msg := range validation.IsValidPortNum(int(*spec.Verification.IngressPort)) {
+				allErrs = append(allErrs, field.Invalid(verificationPath.Child("ingressPort"), *spec.Verification.IngressPort, msg))
+			}
+		}
+	}
+
+	catalogNames := map[string]bool{}
+	for i, v := range spec.CatalogSources {
+		catalogPath := specPath.Child("catalogSources").Index(i)
+		for _, msg := range validation.IsDNS1123Subdomain(v.Name) {
+			allErrs = append(allErrs, field.Invalid(catalogPath.Child("name"), v.Name, msg))
+		}
+		if catalogNames[v.Name] {
+			allErrs = append(allErrs, field.Duplicate(catalogPath.Child("name"), v.Name))
+		}
+		catalogNames[v.Name] = true
+		if v.Image == "" {
+			allErrs = append(allErrs, field.Required(catalogPath.Child("image"), ""))
+		}
+		if v.RegistryPollInterval != "" {
+			if _, err := time.ParseDuration(v.RegistryPollInterval); err != nil {
+				allErrs = append(allErrs, field.Invalid(catalogPath.Child("registryPollInterval"), v.RegistryPollInterval, err.Error()))
+			}
+		}
+	}
+
+	if spec.ACMRegistration != nil {
+		acmPath := specPath.Child("acmRegistration")
+		if err := validateURL(spec.ACMRegistration.URL); err != nil {
+			allErrs = append(allErrs, field.Invalid(acmPath.Child("url"), spec.ACMRegistration.URL, err.Error()))
+		}
+		if spec.ACMRegistration.ClusterName == "" {
+			allErrs = append(allErrs, field.Required(acmPath.Child("clusterName"), ""))
+		} else {
+			// the ClusterName is used as the name of the ManagedCluster, and of its namespace on the ACM cluster
+			for _, msg := range validation.IsDNS1123Label(spec.ACMRegistration.ClusterName) {
+				allErrs = append(allErrs, field.Invalid(acmPath.Child("clusterName"), spec.ACMRegistration.ClusterName, msg))
+			}
+		}
+		allErrs = append(allErrs, validateSecretReference(acmPath.Child("acmSecret"), &spec.ACMRegistration.ACMSecret)...)
+	}
+	return allErrs
+}
+
+type secretReference struct {
+	path       *field.Path
+	ref        *corev1.SecretReference
+	secretType corev1.SecretType
+}
+
+// returns the Secrets referenced by the ClusterRelocation, identified by their paths
+func secretReferences(relocation *rhsysenggithubiov1.ClusterRelocation) []secretReference {
+	var acmSecret *corev1.SecretReference
+	if relocation.Spec.ACMRegistration != nil {
+		acmSecret = &relocation.Spec.ACMRegistration.ACMSecret
+	}
+	var caSecret *corev1.SecretReference
+	if relocation.Spec.CertificateGeneration.GetCAType() == rhsysenggithubiov1.CATypeUser {
+		caSecret = relocation.Spec.CertificateGeneration.CA.SecretRef
+	}
+	specPath := field.NewPath("spec")
+	references := []secretReference{
+		{path: specPath.Child("apiCertRef"), ref: relocation.Spec.APICertRef, secretType: corev1.SecretTypeTLS},
+		{path: specPath.Child("ingressCertRef"), ref: relocation.Spec.IngressCertRef, secretType: corev1.SecretTypeTLS},
+		{path: specPath.Child("pullSecretRef"), ref: relocation.Spec.PullSecretRef, secretType: corev1.SecretTypeDockerConfigJson},
+		{path: specPath.Child("acmRegistration", "acmSecret"), ref: acmSecret, secretType: corev1.SecretTypeOpaque},
+		{path: specPath.Child("certificateGeneration", "ca", "secretRef"), ref: caSecret, secretType: corev1.SecretTypeTLS},
+	}
+	for i, v := range relocation.Spec.ComponentRoutes {
+		references = append(references, secretReference{path: specPath.Child("componentRoutes").Index(i).Child("certRef"), ref: v.CertRef, secretType: corev1.SecretTypeTLS})
+	}
+	for i, v := range relocation.Spec.IngressControllers {
+		references = append(references, secretReference{path: specPath.Child("ingressControllers").Index(i).Child("certRef"), ref: v.CertRef, secretType: corev1.SecretTypeTLS})
+	}
+	return references
+}
+
+// ValidateReferences checks that the Secrets referenced by the ClusterRelocation exist, and have the right types.
+// If old is not nil, only the references which differ from old are checked.
+// This way, a Secret which is deleted once it has been used (such as the ACM secret) doesn't block further updates.
+func ValidateReferences(ctx context.Context, c client.Reader, relocation *rhsysenggithubiov1.ClusterRelocation, old *rhsysenggithubiov1.ClusterRelocation) field.ErrorList {
+	references := secretReferences(relocation)
+	var oldReferences map[string]*corev1.SecretReference
+	if old != nil {
+		oldReferences = map[string]*corev1.SecretReference{}
+		for _, v := range secretReferences(old) {
+			oldReferences[v.path.String()] = v.ref
+		}
+	}
+
+	allErrs := field.ErrorList{}
+	for _, v := range references {
+		if v.ref == nil || v.ref.Name == "" || v.ref.Namespace == "" {
+			// missing names and namespaces are reported by Validate
+			continue
+		}
+		if oldReferences != nil && reflect.DeepEqual(v.ref, oldReferences[v.path.String()]) {
+			continue
+		}
+		if err := secrets.ValidateSecretType(ctx, c, v.ref, v.secretType); err != nil {
+			value := fmt.Sprintf("%s/%s", v.ref.Namespace, v.ref.Name)
+			if errors.IsNotFound(err) {
+				allErrs = append(allErrs, field.NotFound(v.path, value))
+			} else {
+				allErrs = append(allErrs, field.Invalid(v.path, value, err.Error()))
+			}
+		}
+	}
+	return allErrs
+}
+
+func validateDomain(path *field.Path, domain string) field.ErrorList {
+	if domain == "" {
+		return field.ErrorList{field.Required(path, "")}
+	}
+	allErrs := field.ErrorList{}
+	for _, msg := range validation.IsDNS1123Subdomain(domain) {
+		allErrs = append(allErrs, field.Invalid(path, domain, msg))
+	}
+	if len(allErrs) == 0 && !strings.Contains(domain, ".") {
+		allErrs = append(allErrs, field.Invalid(path, domain, "must contain at least two labels"))
+	}
+	return allErrs
+}
+
+func validateDNS(path *field.Path, dns *rhsysenggithubiov1.DNS) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if dns.Addresses != nil {
+		allErrs = append(allErrs, validateIPs(path.Child("addresses", "api"), dns.Addresses.API)...)
+		allErrs = append(allErrs, validateIPs(path.Child("addresses", "ingress"), dns.Addresses.Ingress)...)
+	}
+
+	names := map[string]bool{}
+	for i, v := range dns.Records {
+		recordPath := path.Child("records").Index(i)
+		var msgs []string
+		if strings.HasPrefix(v.Name, "*.") {
+			msgs = validation.IsWildcardDNS1123Subdomain(v.Name)
+		} else {
+			msgs = validation.IsDNS1123Subdomain(v.Name)
+		}
+		for _, msg := range msgs {
+			allErrs = append(allErrs, field.Invalid(recordPath.Child("name"), v.Name, msg))
+		}
+		if names[v.Name] {
+			allErrs = append(allErrs, field.Duplicate(recordPath.Child("name"), v.Name))
+		}
+		names[v.Name] = true
+		allErrs = append(allErrs, validateIPs(recordPath.Child("addresses"), v.Addresses)...)
+	}
+
+	zones := map[string]bool{}
+	for i, v := range dns.Forwarders {
+		forwarderPath := path.Child("forwarders").Index(i)
+		for _, msg := range validation.IsDNS1123Subdomain(v.Zone) {
+			allErrs = append(allErrs, field.Invalid(forwarderPath.Child("zone"), v.Zone, msg))
+		}
+		if zones[v.Zone] {
+			allErrs = append(allErrs, field.Duplicate(forwarderPath.Child("zone"), v.Zone))
+		}
+		zones[v.Zone] = true
+		if len(v.Upstreams) == 0 {
+			allErrs = append(allErrs, field.Required(forwarderPath.Child("upstreams"), "must specify at least one upstream DNS server"))
+		}
+		for j, w := range v.Upstreams {
+			if err := validateUpstream(w); err != nil {
+				allErrs = append(allErrs, field.Invalid(forwarderPath.Child("upstreams").Index(j), w, err.Error()))
+			}
+		}
+	}
+
+	if dns.ClusterDNS != nil {
+		for i, v := range dns.ClusterDNS.Upstreams {
+			if err := validateUpstream(v); err != nil {
+				allErrs = append(allErrs, field.Invalid(path.Child("clusterDNS", "upstreams").Index(i), v, err.Error()))
+			}
+		}
+	}
+
+	if dns.Preflight != nil {
+		for i, v := range dns.Preflight.Nameservers {
+			if err := validateUpstream(v); err != nil {
+				allErrs = append(allErrs, field.Invalid(path.Child("preflight", "nameservers").Index(i), v, err.Error()))
+			}
+		}
+	}
+	return allErrs
+}
+
+func validateCertificateGeneration(path *field.Path, generation *rhsysenggithubiov1.CertificateGeneration) field.ErrorList {
+	allErrs := field.ErrorList{}
+	keySize := generation.GetKeySize()
+	switch generation.GetKeyAlgorithm() {
+	case rhsysenggithubiov1.KeyAlgorithmRSA:
+		if keySize != 2048 && keySize != 3072 && keySize != 4096 {
+			allErrs = append(allErrs, field.NotSupported(path.Child("keySize"), keySize, []string{"2048", "3072", "4096"}))
+		}
+	case rhsysenggithubiov1.KeyAlgorithmECDSA:
+		if keySize != 256 && keySize != 384 {
+			allErrs = append(allErrs, field.NotSupported(path.Child("keySize"), keySize, []string{"256", "384"}))
+		}
+	default:
+		allErrs = append(allErrs, field.NotSupported(path.Child("keyAlgorithm"), generation.KeyAlgorithm, []string{string(rhsysenggithubiov1.KeyAlgorithmRSA), string(rhsysenggithubiov1.KeyAlgorithmECDSA)}))
+	}
+	if validity := generation.GetValidity(); validity < time.Hour {
+		allErrs = append(allErrs, field.Invalid(path.Child("validity"), validity.String(), "must be at least 1h"))
+	}
+	if renewBefore := generation.GetRenewBefore(); renewBefore <= 0 || renewBefore >= generation.GetValidity() {
+		allErrs = append(allErrs, field.Invalid(path.Child("renewBefore"), renewBefore.String(), "must be positive, and shorter than the validity"))
+	}
+	if generation.CA != nil {
+		caPath := path.Child("ca")
+		if generation.GetCAType() == rhsysenggithubiov1.CATypeUser {
+			if generation.CA.SecretRef == nil {
+				allErrs = append(allErrs, field.Required(caPath.Child("secretRef"), "must specify the secret of the CA when the type is User"))
+			}
+			allErrs = append(allErrs, validateSecretReference(caPath.Child("secretRef"), generation.CA.SecretRef)...)
+			if ref := generation.CA.SecretRef; ref != nil && ref.Name == secrets.ManagedCASecretName && ref.Namespace == rhsysenggithubiov1.ConfigNamespace {
+				// this secret holds the CA created by the operator when the type is Managed
+				allErrs = append(allErrs, field.Invalid(caPath.Child("secretRef"), fmt.Sprintf("%s/%s", ref.Namespace, ref.Name), "is reserved for the CA created by the operator"))
+			}
+		} else if generation.CA.SecretRef != nil {
+			allErrs = append(allErrs, field.Forbidden(caPath.Child("secretRef"), "may only be specified when the type is User"))
+		}
+	}
+	allErrs = append(allErrs, validateSubjectAltNames(path.Child("apiAdditionalNames"), generation.APIAdditionalNames)...)
+	allErrs = append(allErrs, validateSubjectAltNames(path.Child("ingressAdditionalNames"), generation.IngressAdditionalNames)...)
+	return allErrs
+}
+
+// checks that the issuer of cert-manager is named, and is an Issuer or a ClusterIssuer when it is in the cert-manager group
+func validateCertManager(path *field.Path, certManager *rhsysenggithubiov1.CertManager) field.ErrorList {
+	allErrs := field.ErrorList{}
+	issuerRefPath := path.Child("issuerRef")
+	if certManager.IssuerRef.Name == "" {
+		allErrs = append(allErrs, field.Required(issuerRefPath.Child("name"), ""))
+	}
+	kind := certManager.IssuerRef.GetKind()
+	if certManager.IssuerRef.GetGroup() == rhsysenggithubiov1.CertManagerGroup && kind != rhsysenggithubiov1.CertManagerIssuerKind && kind != rhsysenggithubiov1.CertManagerClusterIssuerKind {
+		allErrs = append(allErrs, field.NotSupported(issuerRefPath.Child("kind"), kind, []string{rhsysenggithubiov1.CertManagerIssuerKind, rhsysenggithubiov1.CertManagerClusterIssuerKind}))
+	}
+	for _, msg := range validation.IsDNS1123Label(certManager.GetNamespace()) {
+		allErrs = append(allErrs, field.Invalid(path.Child("namespace"), certManager.Namespace, msg))
+	}
+	return allErrs
+}
+
+// checks that the selectors of the RouteReset can be parsed, and that each exclusion is a namespace or <namespace>/<name>
+func validateRouteReset(path *field.Path, routeReset *rhsysenggithubiov1.RouteReset) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if routeReset.NamespaceSelector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(routeReset.NamespaceSelector); err != nil {
+			allErrs = append(allErrs, field.Invalid(path.Child("namespaceSelector"), routeReset.NamespaceSelector, err.Error()))
+		}
+	}
+	if routeReset.RouteSelector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(routeReset.RouteSelector); err != nil {
+			allErrs = append(allErrs, field.Invalid(path.Child("routeSelector"), routeReset.RouteSelector, err.Error()))
+		}
+	}
+	exclusions := map[string]bool{}
+	for i, v := range routeReset.Exclusions {
+		// an exclusion is either a namespace, or <namespace>/<name>
+		namespace, name, found := strings.Cut(v, "/")
+		msgs := validation.IsDNS1123Label(namespace)
+		if found {
+			msgs = append(msgs, validation.IsDNS1123Subdomain(name)...)
+		}
+		for _, msg := range msgs {
+			allErrs = append(allErrs, field.Invalid(path.Child("exclusions").Index(i), v, msg))
+		}
+		if exclusions[v] {
+			allErrs = append(allErrs, field.Duplicate(path.Child("exclusions").Index(i), v))
+		}
+		exclusions[v] = true
+	}
+	return allErrs
+}
+
+// checks that each name is a DNS name (which may be a wildcard) or an IP address
+func validateSubjectAltNames(path *field.Path, names []string) field.ErrorList {
+	allErrs := field.ErrorList{}
+	for i, v := range names {
+		if net.ParseIP(v) != nil {
+			continue
+		}
+		var msgs []string
+		if strings.HasPrefix(v, "*.") {
+			msgs = validation.IsWildcardDNS1123Subdomain(v)
+		} else {
+			msgs = validation.IsDNS1123Subdomain(v)
+		}
+		for _, msg := range msgs {
+			allErrs = append(allErrs, field.Invalid(path.Index(i), v, msg))
+		}
+	}
+	return allErrs
+}
+
+// checks that upstream is an IP address, optionally followed by a port
+func validateUpstream(upstream string) error {
+	host, port, err := net.SplitHostPort(upstream)
+	if err != nil {
+		// no port
+		host = upstream
+	} else {
+		portNumber, err := strconv.Atoi(port)
+		if err != nil {
+			return fmt.Errorf("invalid port %s", port)
+		}
+		if msgs := validation.IsValidPortNum(portNumber); len(msgs) > 0 {
+			return fmt.Errorf("%s", strings.Join(msgs, ", "))
+		}
+	}
+	if net.ParseIP(host) == nil {
+		return fmt.Errorf("must be an IP address, optionally followed by a port")
+	}
+	return nil
+}
+
+func validateIPs(path *field.Path, ips []string) field.ErrorList {
+	if len(ips) == 0 {
+		return field.ErrorList{field.Required(path, "must specify at least one IP address")}
+	}
+	allErrs := field.ErrorList{}
+	for i, v := range ips {
+		for _, msg := range validation.IsValidIP(v) {
+			allErrs = append(allErrs, field.Invalid(path.Index(i), v, msg))
+		}
+	}
+	return allErrs
+}
+
+func validateSecretReference(path *field.Path, ref *corev1.SecretReference) field.ErrorList {
+	if ref == nil {
+		return nil
+	}
+	allErrs := field.ErrorList{}
+	if ref.Name == "" {
+		allErrs = append(allErrs, field.Required(path.Child("name"), "must specify secret name and namespace"))
+	}
+	if ref.Namespace == "" {
+		allErrs = append(allErrs, field.Required(path.Child("namespace"), "must specify secret name and namespace"))
+	}
+	return allErrs
+}
+
+// checks that data contains at least one PEM encoded certificate, and nothing else
+func validateCertificates(data string) error {
+	rest := bytes.TrimSpace([]byte(data))
+	if len(rest) == 0 {
+		return fmt.Errorf("must contain a PEM encoded certificate")
+	}
+	for len(rest) > 0 {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return fmt.Errorf("could not decode PEM data")
+		}
+		if block.Type != "CERTIFICATE" {
+			return fmt.Errorf("unexpected PEM block of type %s", block.Type)
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return err
+		}
+		rest = bytes.TrimSpace(rest)
+	}
+	return nil
+}
+
+// checks that key is a single line in the authorized_keys format: <type> <base64 encoded key> [comment]
+func validateAuthorizedKey(key string) error {
+	if strings.ContainsAny(key, "\r\n") {
+		return fmt.Errorf("must be a single line")
+	}
+	fields := strings.Fields(key)
+	if len(fields) < 2 {
+		return fmt.Errorf("must be in the format '<type> <key> [comment]'")
+	}
+	blob, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return fmt.Errorf("could not decode key: %w", err)
+	}
+	// the key blob starts with its type, as a length-prefixed string
+	if len(blob) < 4 {
+		return fmt.Errorf("key is too short")
+	}
+	length := binary.BigEndian.Uint32(blob)
+	if uint64(len(blob)) < 4+uint64(length) || string(blob[4:4+length]) != fields[0] {
+		return fmt.Errorf("key does not match its type %s", fields[0])
+	}
+	return nil
+}
+
+func validateURL(rawURL string) error {
+	if rawURL == "" {
+		return fmt.Errorf("must specify the URL of the ACM cluster")
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "https" {
+		return fmt.Errorf("scheme must be https")
+	}
+	if u.Host == "" {
+		return fmt.Errorf("must specify a host")
+	}
+	return nil
+}
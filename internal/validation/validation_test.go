@@ -0,0 +1,256 @@
+package validation
+
+import (
+	"testing"
+
+	rhsysenggithubiov1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
+	secrets "github.com/RHsyseng/cluster-relocation-operator/internal/secrets"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidate(t *testing.T) {
+	rsa1024 := int32(1024)
+	tests := []struct {
+		name   string
+		modify func(relocation *rhsysenggithubiov1.ClusterRelocation)
+		// the paths of the expected errors, in order
+		want []string
+	}{
+		{
+			name:   "valid",
+			modify: func(relocation *rhsysenggithubiov1.ClusterRelocation) {},
+		},
+		{
+			name: "wrong name",
+			modify: func(relocation *rhsysenggithubiov1.ClusterRelocation) {
+				relocation.Name = "other"
+			},
+			want: []string{"metadata.name"},
+		},
+		{
+			name: "missing domain",
+			modify: func(relocation *rhsysenggithubiov1.ClusterRelocation) {
+				relocation.Spec.Domain = ""
+			},
+			want: []string{"spec.domain"},
+		},
+		{
+			name: "single label domain",
+			modify: func(relocation *rhsysenggithubiov1.ClusterRelocation) {
+				relocation.Spec.Domain = "example"
+			},
+			want: []string{"spec.domain"},
+		},
+		{
+			name: "secret reference without namespace",
+			modify: func(relocation *rhsysenggithubiov1.ClusterRelocation) {
+				relocation.Spec.APICertRef = &corev1.SecretReference{Name: "api-cert"}
+			},
+			want: []string{"spec.apiCertRef.namespace"},
+		},
+		{
+			name: "registry hostname with a port",
+			modify: func(relocation *rhsysenggithubiov1.ClusterRelocation) {
+				relocation.Spec.RegistryCerts = []rhsysenggithubiov1.RegistryCert{
+					{RegistryHostname: "registry.example.com:5000", Certificate: "not a certificate"},
+				}
+			},
+			want: []string{"spec.registryCerts[0].registryHostname", "spec.registryCerts[0].certificate"},
+		},
+		{
+			name: "valid DNS records",
+			modify: func(relocation *rhsysenggithubiov1.ClusterRelocation) {
+				relocation.Spec.DNS = &rhsysenggithubiov1.DNS{
+					Records: []rhsysenggithubiov1.DNSRecord{
+						{Name: "registry.example.com", Addresses: []string{"192.168.1.10"}},
+						{Name: "*.hub.example.com", Addresses: []string{"192.168.1.11", "fd00::11"}},
+					},
+				}
+			},
+		},
+		{
+			name: "invalid DNS records",
+			modify: func(relocation *rhsysenggithubiov1.ClusterRelocation) {
+				relocation.Spec.DNS = &rhsysenggithubiov1.DNS{
+					Records: []rhsysenggithubiov1.DNSRecord{
+						{Name: "*.*.example.com", Addresses: []string{"192.168.1.10"}},
+						{Name: "registry.example.com", Addresses: []string{"registry"}},
+						{Name: "registry.example.com", Addresses: []string{"192.168.1.10"}},
+						{Name: "ntp.example.com"},
+					},
+				}
+			},
+			want: []string{
+				"spec.dns.records[0].name",
+				"spec.dns.records[1].addresses[0]",
+				"spec.dns.records[2].name",
+				"spec.dns.records[3].addresses",
+			},
+		},
+		{
+			name: "valid upstreams",
+			modify: func(relocation *rhsysenggithubiov1.ClusterRelocation) {
+				relocation.Spec.DNS = &rhsysenggithubiov1.DNS{
+					Forwarders: []rhsysenggithubiov1.DNSForwarder{
+						{Zone: "example.com", Upstreams: []string{"192.168.1.1", "192.168.1.1:5353", "fd00::1", "[fd00::1]:53"}},
+					},
+					ClusterDNS: &rhsysenggithubiov1.ClusterDNS{Upstreams: []string{"192.168.1.1"}},
+					Preflight:  &rhsysenggithubiov1.DNSPreflight{Nameservers: []string{"192.168.1.1:53"}},
+				}
+			},
+		},
+		{
+			name: "invalid upstreams",
+			modify: func(relocation *rhsysenggithubiov1.ClusterRelocation) {
+				relocation.Spec.DNS = &rhsysenggithubiov1.DNS{
+					Forwarders: []rhsysenggithubiov1.DNSForwarder{
+						{Zone: "example.com", Upstreams: []string{"dns.example.com", "192.168.1.1:99999", "192.168.1.1:dns"}},
+						{Zone: "example.com"},
+					},
+					ClusterDNS: &rhsysenggithubiov1.ClusterDNS{Upstreams: []string{"dns.example.com:53"}},
+					Preflight:  &rhsysenggithubiov1.DNSPreflight{Nameservers: []string{"10.0.0.1:53:53"}},
+				}
+			},
+			want: []string{
+				"spec.dns.forwarders[0].upstreams[0]",
+				"spec.dns.forwarders[0].upstreams[1]",
+				"spec.dns.forwarders[0].upstreams[2]",
+				"spec.dns.forwarders[1].zone",
+				"spec.dns.forwarders[1].upstreams",
+				"spec.dns.clusterDNS.upstreams[0]",
+				"spec.dns.preflight.nameservers[0]",
+			},
+		},
+		{
+			name: "invalid DNS addresses",
+			modify: func(relocation *rhsysenggithubiov1.ClusterRelocation) {
+				relocation.Spec.DNS = &rhsysenggithubiov1.DNS{
+					Addresses: &rhsysenggithubiov1.DNSAddresses{API: []string{"192.168.1.300"}},
+				}
+			},
+			want: []string{"spec.dns.addresses.api[0]", "spec.dns.addresses.ingress"},
+		},
+		{
+			name: "invalid certificate generation",
+			modify: func(relocation *rhsysenggithubiov1.ClusterRelocation) {
+				relocation.Spec.CertificateGeneration = &rhsysenggithubiov1.CertificateGeneration{
+					KeySize:                &rsa1024,
+					APIAdditionalNames:     []string{"api.example.com", "192.168.1.1", "*.api.example.com"},
+					IngressAdditionalNames: []string{"*.*.example.com", "bad_name.example.com"},
+				}
+			},
+			want: []string{
+				"spec.certificateGeneration.keySize",
+				"spec.certificateGeneration.ingressAdditionalNames[0]",
+				"spec.certificateGeneration.ingressAdditionalNames[1]",
+			},
+		},
+		{
+			name: "User CA without secret",
+			modify: func(relocation *rhsysenggithubiov1.ClusterRelocation) {
+				relocation.Spec.CertificateGeneration = &rhsysenggithubiov1.CertificateGeneration{
+					CA: &rhsysenggithubiov1.CertificateAuthority{Type: rhsysenggithubiov1.CATypeUser},
+				}
+			},
+			want: []string{"spec.certificateGeneration.ca.secretRef"},
+		},
+		{
+			name: "User CA with the secret of the managed CA",
+			modify: func(relocation *rhsysenggithubiov1.ClusterRelocation) {
+				relocation.Spec.CertificateGeneration = &rhsysenggithubiov1.CertificateGeneration{
+					CA: &rhsysenggithubiov1.CertificateAuthority{
+						Type:      rhsysenggithubiov1.CATypeUser,
+						SecretRef: &corev1.SecretReference{Name: secrets.ManagedCASecretName, Namespace: rhsysenggithubiov1.ConfigNamespace},
+					},
+				}
+			},
+			want: []string{"spec.certificateGeneration.ca.secretRef"},
+		},
+		{
+			name: "Managed CA with a secret",
+			modify: func(relocation *rhsysenggithubiov1.ClusterRelocation) {
+				relocation.Spec.CertificateGeneration = &rhsysenggithubiov1.CertificateGeneration{
+					CA: &rhsysenggithubiov1.CertificateAuthority{
+						Type:      rhsysenggithubiov1.CATypeManaged,
+						SecretRef: &corev1.SecretReference{Name: "ca", Namespace: "openshift-config"},
+					},
+				}
+			},
+			want: []string{"spec.certificateGeneration.ca.secretRef"},
+		},
+		{
+			name: "route reset",
+			modify: func(relocation *rhsysenggithubiov1.ClusterRelocation) {
+				relocation.Spec.RouteReset = &rhsysenggithubiov1.RouteReset{
+					RouteSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "app", Operator: "Unknown"}}},
+					Exclusions:    []string{"app-ns", "app-ns/route", "Bad_Namespace", "app-ns/route"},
+				}
+			},
+			want: []string{"spec.routeReset.routeSelector", "spec.routeReset.exclusions[2]", "spec.routeReset.exclusions[3]"},
+		},
+		{
+			name: "additional IngressControllers",
+			modify: func(relocation *rhsysenggithubiov1.ClusterRelocation) {
+				relocation.Spec.IngressControllers = []rhsysenggithubiov1.IngressController{
+					{Name: "shard", Domain: "shard.new.example.com"},
+					{Name: rhsysenggithubiov1.DefaultIngressController, Domain: "apps.new.example.com"},
+					{Name: "shard", Domain: "shard"},
+				}
+			},
+			want: []string{
+				"spec.ingressControllers[1].name",
+				"spec.ingressControllers[2].name",
+				"spec.ingressControllers[2].domain",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			relocation := &rhsysenggithubiov1.ClusterRelocation{
+				ObjectMeta: metav1.ObjectMeta{Name: RelocationName},
+				Spec:       rhsysenggithubiov1.ClusterRelocationSpec{Domain: "new.example.com"},
+			}
+			tt.modify(relocation)
+			var got []string
+			for _, v := range Validate(relocation) {
+				got = append(got, v.Field)
+			}
+			if diff := cmp.Diff(tt.want, got, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("unexpected errors (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateUpstream(t *testing.T) {
+	tests := []struct {
+		upstream string
+		valid    bool
+	}{
+		{upstream: "192.168.1.1", valid: true},
+		{upstream: "192.168.1.1:53", valid: true},
+		{upstream: "fd00::1", valid: true},
+		{upstream: "[fd00::1]:5353", valid: true},
+		{upstream: "", valid: false},
+		{upstream: "dns.example.com", valid: false},
+		{upstream: "dns.example.com:53", valid: false},
+		{upstream: "192.168.1.1:0", valid: false},
+		{upstream: "192.168.1.1:65536", valid: false},
+		{upstream: "192.168.1.1:dns", valid: false},
+		{upstream: "[fd00::1]", valid: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.upstream, func(t *testing.T) {
+			err := validateUpstream(tt.upstream)
+			if tt.valid && err != nil {
+				t.Errorf("validateUpstream(%q) returned %v, want no error", tt.upstream, err)
+			}
+			if !tt.valid && err == nil {
+				t.Errorf("validateUpstream(%q) returned no error", tt.upstream)
+			}
+		})
+	}
+}
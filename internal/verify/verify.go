@@ -0,0 +1,184 @@
+package verify
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	rhsysenggithubiov1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
+	secrets "github.com/RHsyseng/cluster-relocation-operator/internal/secrets"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/step"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/util"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+
+// the endpoint is given up after this delay, so that an unresponsive endpoint doesn't block the reconcile
+const dialTimeout = 10 * time.Second
+
+// Ingress checks that the ingress serves a certificate for the given domain, signed by one of roots.
+// If roots is nil, the chain of the certificate is not verified
+func Ingress(ctx context.Context, c client.Client, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger, domainName string, roots *x509.CertPool) error {
+	host := fmt.Sprintf("test.apps.%s", domainName)
+	port := relocation.Spec.Verification.GetIngressPort()
+	return endpoint(ctx, c, relocation, logger, rhsysenggithubiov1.EndpointIngress, "ingress", host, port, roots)
+}
+
+// API checks that the API server serves a certificate for each of the given hostnames, signed by one of roots.
+// The first hostname is reported as the API endpoint, and the others as API/<hostname>.
+// If roots is nil, the chain of the certificate is not verified
+func API(ctx context.Context, c client.Client, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger, hosts []string, roots *x509.CertPool) error {
+	// the hostnames which are no longer served are no longer reported
+	endpoints := []rhsysenggithubiov1.EndpointStatus{}
+	for _, v := range relocation.Status.Endpoints {
+		if !strings.HasPrefix(v.Name, rhsysenggithubiov1.EndpointAPI+"/") || containsHost(hosts[1:], strings.TrimPrefix(v.Name, rhsysenggithubiov1.EndpointAPI+"/")) {
+			endpoints = append(endpoints, v)
+		}
+	}
+	if len(endpoints) == 0 {
+		endpoints = nil
+	}
+	relocation.Status.Endpoints = endpoints
+
+	port := relocation.Spec.Verification.GetAPIPort()
+	for i, host := range hosts {
+		name := rhsysenggithubiov1.EndpointAPI
+		if i > 0 {
+			name = fmt.Sprintf("%s/%s", rhsysenggithubiov1.EndpointAPI, host)
+		}
+		if err := endpoint(ctx, c, relocation, logger, name, "kube-apiserver", host, port, roots); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExpectedCA returns the CA certificates that the certificate of an endpoint is expected to be signed by.
+// If certRef is nil, the certificate is generated and signed by the CA selected in the CertificateGeneration settings.
+// Otherwise, this is the ca.crt of the secret if it has one, or else the system roots along with the CA certificates included in tls.crt
+func ExpectedCA(ctx context.Context, c client.Client, relocation *rhsysenggithubiov1.ClusterRelocation, certRef *corev1.SecretReference) (*x509.CertPool, error) {
+	if certRef == nil {
+		signer, err := secrets.GetSigner(ctx, c, relocation)
+		if err != nil {
+			return nil, err
+		}
+		roots := x509.NewCertPool()
+		roots.AddCert(signer.Certificate)
+		return roots, nil
+	}
+
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{Name: certRef.Name, Namespace: certRef.Namespace}, secret); err != nil {
+		return nil, err
+	}
+	return secrets.TrustedRoots(secret)
+}
+
+// checks that the endpoint presents a valid certificate for host, and that the ClusterOperator serving the endpoint has settled.
+// Returns a WaitingError while the endpoint is unreachable or serves a certificate for another name, since it is being reconfigured.
+// Returns an error if the certificate is for the right name but can't be verified, since that won't resolve by itself.
+// The result is recorded in the status either way
+func endpoint(ctx context.Context, c client.Client, relocation *rhsysenggithubiov1.ClusterRelocation, logger logr.Logger, name string, operator string, host string, port int32, roots *x509.CertPool) error {
+	timeouts := relocation.Spec.Timeouts
+	address := net.JoinHostPort(host, strconv.Itoa(int(port)))
+	status := rhsysenggithubiov1.EndpointStatus{Name: name, Address: address}
+	defer func() {
+		setEndpointStatus(relocation, status)
+	}()
+
+	// the certificate is verified below rather than by the handshake, in order to report exactly what doesn't match
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: dialTimeout},
+		Config:    &tls.Config{ServerName: host, InsecureSkipVerify: true},
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		// the endpoint is often unavailable while it is being reconfigured
+		logger.Info(fmt.Sprintf("Waiting for %s to become reachable", operator), "error", err.Error())
+		status.Error = fmt.Sprintf("unreachable: %s", err.Error())
+		return step.Wait("%s to be reachable at %s", operator, address).WithTimeout(timeouts.GetEndpointVerification())
+	}
+	certs := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	conn.Close()
+	if len(certs) == 0 {
+		status.Error = "no certificate was presented"
+		return step.Wait("%s to serve a certificate for %s", operator, host).WithTimeout(timeouts.GetEndpointVerification())
+	}
+
+	cert := certs[0]
+	status.DNSNames = cert.DNSNames
+	status.Issuer = cert.Issuer.String()
+	notAfter := metav1.NewTime(cert.NotAfter)
+	status.NotAfter = &notAfter
+
+	if err := cert.VerifyHostname(host); err != nil {
+		// the previous certificate is still served until the operator has rolled out the new one
+		logger.Info(fmt.Sprintf("Waiting for %s to update", operator), "DNSNames", cert.DNSNames)
+		status.Error = fmt.Sprintf("the certificate is valid for %s, not %s", describeNames(cert), host)
+		return step.Wait("%s to serve a certificate for %s", operator, host).WithTimeout(timeouts.GetEndpointVerification())
+	}
+
+	now := time.Now()
+	if now.After(cert.NotAfter) {
+		status.Error = fmt.Sprintf("the certificate expired at %s", cert.NotAfter.UTC().Format(time.RFC3339))
+		return errors.New(status.Error)
+	}
+	if now.Before(cert.NotBefore) {
+		status.Error = fmt.Sprintf("the certificate is not valid before %s", cert.NotBefore.UTC().Format(time.RFC3339))
+		return errors.New(status.Error)
+	}
+
+	if roots != nil {
+		intermediates := x509.NewCertPool()
+		for _, v := range certs[1:] {
+			intermediates.AddCert(v)
+		}
+		opts := x509.VerifyOptions{Roots: roots, Intermediates: intermediates, CurrentTime: now}
+		if _, err := cert.Verify(opts); err != nil {
+			status.Error = fmt.Sprintf("the certificate is not signed by the expected CA: %s", err.Error())
+			return errors.New(status.Error)
+		}
+	}
+
+	status.Verified = true
+	// ensure that ClusterOperator has settled
+	return util.WaitForCO(ctx, c, logger, operator, timeouts.GetClusterOperatorSettle())
+}
+
+// replaces the status of the endpoint with the given name, so that the list keeps a stable order
+func setEndpointStatus(relocation *rhsysenggithubiov1.ClusterRelocation, status rhsysenggithubiov1.EndpointStatus) {
+	for i, v := range relocation.Status.Endpoints {
+		if v.Name == status.Name {
+			relocation.Status.Endpoints[i] = status
+			return
+		}
+	}
+	relocation.Status.Endpoints = append(relocation.Status.Endpoints, status)
+}
+
+func containsHost(hosts []string, host string) bool {
+	for _, v := range hosts {
+		if v == host {
+			return true
+		}
+	}
+	return false
+}
+
+func describeNames(cert *x509.Certificate) string {
+	if len(cert.DNSNames) == 0 {
+		return fmt.Sprintf("no DNS names (common name %s)", cert.Subject.CommonName)
+	}
+	return strings.Join(cert.DNSNames, ", ")
+}
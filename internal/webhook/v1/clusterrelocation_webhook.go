@@ -0,0 +1,126 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"fmt"
+
+	rhsysenggithubiov1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/validation"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var clusterrelocationlog = logf.Log.WithName("clusterrelocation-webhook")
+
+// SetupClusterRelocationWebhookWithManager registers the webhooks for ClusterRelocation with the manager
+func SetupClusterRelocationWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&rhsysenggithubiov1.ClusterRelocation{}).
+		WithDefaulter(&ClusterRelocationDefaulter{}).
+		// the Secrets are read directly from the API server, so that a Secret created right before the CR is found
+		WithValidator(&ClusterRelocationValidator{Reader: mgr.GetAPIReader()}).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-rhsyseng-github-io-v1-clusterrelocation,mutating=true,failurePolicy=fail,sideEffects=None,groups=rhsyseng.github.io,resources=clusterrelocations,verbs=create;update,versions=v1,name=mclusterrelocation.kb.io,admissionReviewVersions=v1
+
+// ClusterRelocationDefaulter sets the defaults of the optional fields,
+// so that the stored spec shows the effective configuration
+type ClusterRelocationDefaulter struct{}
+
+var _ admission.CustomDefaulter = &ClusterRelocationDefaulter{}
+
+// Default implements admission.CustomDefaulter
+func (d *ClusterRelocationDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	relocation, ok := obj.(*rhsysenggithubiov1.ClusterRelocation)
+	if !ok {
+		return fmt.Errorf("expected a ClusterRelocation but got a %T", obj)
+	}
+	clusterrelocationlog.Info("default", "name", relocation.Name)
+
+	relocation.Default()
+	return nil
+}
+
+//+kubebuilder:webhook:path=/validate-rhsyseng-github-io-v1-clusterrelocation,mutating=false,failurePolicy=fail,sideEffects=None,groups=rhsyseng.github.io,resources=clusterrelocations,verbs=create;update,versions=v1,name=vclusterrelocation.kb.io,admissionReviewVersions=v1
+
+// ClusterRelocationValidator rejects invalid ClusterRelocations at admission time,
+// rather than letting the reconcile fail part way through the relocation
+type ClusterRelocationValidator struct {
+	Reader client.Reader
+}
+
+var _ admission.CustomValidator = &ClusterRelocationValidator{}
+
+// ValidateCreate implements admission.CustomValidator
+func (v *ClusterRelocationValidator) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	relocation, ok := obj.(*rhsysenggithubiov1.ClusterRelocation)
+	if !ok {
+		return fmt.Errorf("expected a ClusterRelocation but got a %T", obj)
+	}
+	clusterrelocationlog.Info("validate create", "name", relocation.Name)
+
+	allErrs := validation.Validate(relocation)
+	allErrs = append(allErrs, validation.ValidateReferences(ctx, v.Reader, relocation, nil)...)
+	return toError(relocation, allErrs)
+}
+
+// ValidateUpdate implements admission.CustomValidator
+func (v *ClusterRelocationValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	relocation, ok := newObj.(*rhsysenggithubiov1.ClusterRelocation)
+	if !ok {
+		return fmt.Errorf("expected a ClusterRelocation but got a %T", newObj)
+	}
+	oldRelocation, ok := oldObj.(*rhsysenggithubiov1.ClusterRelocation)
+	if !ok {
+		return fmt.Errorf("expected a ClusterRelocation but got a %T", oldObj)
+	}
+	if relocation.GetDeletionTimestamp() != nil {
+		// never block the removal of the finalizer
+		return nil
+	}
+	if equality.Semantic.DeepEqual(relocation.Spec, oldRelocation.Spec) {
+		// the metadata changed (e.g. the finalizer was added), or the CR was rewritten by the storage version migration.
+		// Don't block these updates if the CR was created before the validation was added
+		return nil
+	}
+	clusterrelocationlog.Info("validate update", "name", relocation.Name)
+
+	allErrs := validation.Validate(relocation)
+	allErrs = append(allErrs, validation.ValidateReferences(ctx, v.Reader, relocation, oldRelocation)...)
+	return toError(relocation, allErrs)
+}
+
+// ValidateDelete implements admission.CustomValidator
+func (v *ClusterRelocationValidator) ValidateDelete(ctx context.Context, obj runtime.Object) error {
+	return nil
+}
+
+func toError(relocation *rhsysenggithubiov1.ClusterRelocation, allErrs field.ErrorList) error {
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return errors.NewInvalid(rhsysenggithubiov1.GroupVersion.WithKind("ClusterRelocation").GroupKind(), relocation.Name, allErrs)
+}
@@ -26,6 +26,7 @@ import (
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -33,8 +34,11 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	logzap "sigs.k8s.io/controller-runtime/pkg/log/zap"
 
+	rhsysenggithubiov1 "github.com/RHsyseng/cluster-relocation-operator/api/v1"
 	rhsysenggithubiov1beta1 "github.com/RHsyseng/cluster-relocation-operator/api/v1beta1"
 	"github.com/RHsyseng/cluster-relocation-operator/controllers"
+	"github.com/RHsyseng/cluster-relocation-operator/internal/migration"
+	webhookv1 "github.com/RHsyseng/cluster-relocation-operator/internal/webhook/v1"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -46,7 +50,10 @@ var (
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
+	utilruntime.Must(apiextensionsv1.AddToScheme(scheme))
+
 	utilruntime.Must(rhsysenggithubiov1beta1.AddToScheme(scheme))
+	utilruntime.Must(rhsysenggithubiov1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -107,6 +114,23 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "ClusterRelocation")
 		os.Exit(1)
 	}
+	// the webhooks can be disabled when running the operator locally (e.g. with "make run"), since they need a serving certificate.
+	// This also registers the conversion webhook between v1beta1 and v1
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = webhookv1.SetupClusterRelocationWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ClusterRelocation")
+			os.Exit(1)
+		}
+	}
+	// rewrite the ClusterRelocations stored as v1beta1, so that v1beta1 can eventually be removed from the CRD
+	if err = mgr.Add(&migration.StorageVersionMigrator{
+		Client: mgr.GetClient(),
+		Reader: mgr.GetAPIReader(),
+		Logger: ctrl.Log.WithName("storage-version-migration"),
+	}); err != nil {
+		setupLog.Error(err, "unable to set up storage version migration")
+		os.Exit(1)
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {